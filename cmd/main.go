@@ -2,7 +2,12 @@ package main
 
 //只负责启动，不负责具体配置细节
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"seckill/internal/model"
 	"seckill/internal/router"
@@ -10,9 +15,11 @@ import (
 	"seckill/pkg/config"
 	"seckill/pkg/database"
 	"seckill/pkg/logger"
+	"seckill/pkg/payment"
 	"seckill/pkg/rabbitmq"
 	"seckill/pkg/redis"
 	"seckill/pkg/snowflake"
+	"seckill/pkg/utils"
 
 	"go.uber.org/zap"
 )
@@ -32,6 +39,15 @@ func main() {
 		log.Fatalf("配置加载失败: %v", err)
 	}
 
+	// 命令行子命令（不启动服务）
+	// go run cmd/main.go config  打印脱敏后的最终生效配置
+	// go run cmd/main.go role <username> <role>  分配角色
+	// go run cmd/main.go warmup [lead]  立即预热场次库存
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1:])
+		return
+	}
+
 	// 1、初始化各组件
 	logger.Initlogger()
 	defer logger.Sync()     // 确保程序退出前最后一条日志被写入
@@ -40,17 +56,46 @@ func main() {
 	redis.InitLuaScripts()  // 初始化 Lua 脚本
 	snowflake.Init(1)       // 雪花算法初始化，机器ID=1
 	rabbitmq.InitRabbitMQ() // RabbitMQ初始化
-	service.StartConsumer()
+	if err := utils.InitJWTKeys(); err != nil {
+		logger.Log.Fatal("JWT 密钥加载失败", zap.Error(err))
+	}
+	if err := payment.Init(); err != nil {
+		logger.Log.Fatal("支付渠道初始化失败", zap.Error(err))
+	}
 
 	// 2、表结构设置
-	err := database.DB.AutoMigrate(&model.User{}, &model.Product{}, &model.Order{}) // 自动建表
+	// 金额列改为整数（分），必须在 AutoMigrate 改列类型之前转换数据
+	if err := service.MigrateMoneyColumns(); err != nil {
+		logger.Log.Fatal("金额列迁移失败", zap.Error(err))
+	}
+	err := database.DB.AutoMigrate(
+		&model.User{},
+		&model.Product{},
+		&model.SeckillActivity{},
+		&model.SeckillSession{},
+		&model.Order{},
+		&model.OrderStatusHistory{},
+		&model.Payment{},
+		&model.Refund{},
+		&model.Address{},
+	) // 自动建表
 	if err != nil {
 		logger.Log.Fatal("建表失败", zap.Error(err))
 	}
+	if err := service.RunMigrations(); err != nil {
+		logger.Log.Fatal("数据迁移失败", zap.Error(err))
+	}
 	logger.Log.Info("数据库表结构同步成功")
 
-	// 3、初始化测试商品数据
+	// 3、初始化测试商品数据，启动消费者和定时任务
+	// 消费者写入的订单列（支付截止时间、快照、地址、金额）都依赖上面的表结构迁移，必须放在迁移之后启动
 	service.InitProductData()
+	service.StartOrderEventHub()        // 订单结果推送
+	service.StartConsumer()             // 异步下单
+	service.StartOrderTimeoutConsumer() // 订单支付超时取消
+	service.StartRefundRetryScheduler() // 重新提交结果未知的退款
+	service.StartWarmupScheduler()
+	service.StartCleanupScheduler()
 
 	// 4、启动web服务
 	r := router.NewRouter()
@@ -62,3 +107,49 @@ func main() {
 	)
 	r.Run(config.GetServerAddr())
 }
+
+// runCommand 执行命令行子命令
+func runCommand(args []string) {
+	switch args[0] {
+	case "config":
+		out, err := json.MarshalIndent(config.Effective(), "", "  ")
+		if err != nil {
+			log.Fatalf("导出配置失败: %v", err)
+		}
+		fmt.Println(string(out))
+	case "role":
+		// 分配角色，用于初始化第一个管理员: go run cmd/main.go role <username> admin
+		if len(args) != 3 {
+			log.Fatalf("用法: role <username> <user|support|operator|admin>")
+		}
+		logger.Initlogger()
+		database.InitMySQL()
+		if err := database.DB.AutoMigrate(&model.User{}); err != nil {
+			log.Fatalf("建表失败: %v", err)
+		}
+		if err := service.AssignRoleByUsername(args[1], args[2]); err != nil {
+			log.Fatalf("分配角色失败: %v", err)
+		}
+		fmt.Printf("✅ 用户 %s 的角色已设置为 %s，重新登录后生效\n", args[1], args[2])
+	case "warmup":
+		// 预热提前时长默认取配置，例如大促前: go run cmd/main.go warmup 24h
+		lead := config.Get().Warmup.LeadTime
+		if len(args) > 1 {
+			d, err := time.ParseDuration(args[1])
+			if err != nil {
+				log.Fatalf("提前时长格式错误: %v", err)
+			}
+			lead = d
+		}
+		logger.Initlogger()
+		database.InitMySQL()
+		redis.InitRedis()
+		n, err := service.WarmUpSessions(context.Background(), lead)
+		if err != nil {
+			log.Fatalf("库存预热失败: %v", err)
+		}
+		fmt.Printf("✅ 已预热 %d 个场次（%s 内开始）\n", n, lead)
+	default:
+		log.Fatalf("未知命令: %s（可用命令: config, role, warmup）", args[0])
+	}
+}
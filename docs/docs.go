@@ -15,9 +15,1946 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "返回 access token 验签公钥（JWKS 格式），包含当前密钥和轮换中的旧密钥",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "JWT 公钥集",
+                "responses": {
+                    "200": {
+                        "description": "{\"keys\": [{\"kty\": \"OKP\", \"crv\": \"Ed25519\", \"kid\": \"jwt-2026-10\", \"alg\": \"EdDSA\", \"use\": \"sig\", \"x\": \"...\"}]}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/addresses": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "默认地址排在最前",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "收货地址列表",
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":[{\"id\":1,\"receiver\":\"张三\",\"phone\":\"13800000000\",\"province\":\"浙江省\",\"city\":\"杭州市\",\"district\":\"西湖区\",\"detail\":\"文三路 1 号\",\"is_default\":true}]}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "第一个地址自动设为默认地址，每人最多 20 个",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "添加收货地址",
+                "parameters": [
+                    {
+                        "description": "收货地址",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "city": {
+                                    "type": "string"
+                                },
+                                "detail": {
+                                    "type": "string"
+                                },
+                                "district": {
+                                    "type": "string"
+                                },
+                                "is_default": {
+                                    "type": "boolean"
+                                },
+                                "phone": {
+                                    "type": "string"
+                                },
+                                "province": {
+                                    "type": "string"
+                                },
+                                "receiver": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"id\":1,\"receiver\":\"张三\",\"is_default\":true}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/addresses/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "is_default 为 true 时同时设为默认地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "修改收货地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "收货地址",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "city": {
+                                    "type": "string"
+                                },
+                                "detail": {
+                                    "type": "string"
+                                },
+                                "district": {
+                                    "type": "string"
+                                },
+                                "is_default": {
+                                    "type": "boolean"
+                                },
+                                "phone": {
+                                    "type": "string"
+                                },
+                                "province": {
+                                    "type": "string"
+                                },
+                                "receiver": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"id\":1,\"receiver\":\"张三\",\"is_default\":true}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除默认地址时最近添加的地址成为默认地址，已下单的订单不受影响",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "删除收货地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"删除成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/addresses/{id}/default": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "收货地址"
+                ],
+                "summary": "设为默认地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "地址ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"设置成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/activities": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "分页查询秒杀活动",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "秒杀活动列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...], \"total\": 10, \"page\": 1, \"size\": 20}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "创建活动，之后在活动下添加场次",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "创建秒杀活动",
+                "parameters": [
+                    {
+                        "description": "活动信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ActivityInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"创建成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/activities/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "查询活动及其全部场次，包含 MySQL 库存和 Redis 剩余可抢库存",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "秒杀活动详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "修改活动名称和描述",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "修改秒杀活动",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要修改的字段",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ActivityInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"修改成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除活动及其全部场次，有进行中的场次时不能删除（返回 409）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "删除秒杀活动",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"删除成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/activities/{id}/sessions": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "在活动下创建场次，并把库存、时间和限购写入 Redis；时间格式 RFC3339",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "创建秒杀场次",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "场次信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SessionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"创建成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/blacklist": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "按类型列出风控黑名单",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "查询黑名单",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "黑名单类型 user/ip/device",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...]}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "将用户ID/IP/设备ID加入风控黑名单，可设置过期时间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "添加黑名单",
+                "parameters": [
+                    {
+                        "description": "type: user/ip/device，ttl: 过期秒数（0 为永久）",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                },
+                                "ttl": {
+                                    "type": "integer"
+                                },
+                                "type": {
+                                    "type": "string"
+                                },
+                                "value": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"添加成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/blacklist/{type}/{value}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "将用户ID/IP/设备ID移出风控黑名单",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "移除黑名单",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "黑名单类型 user/ip/device",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户ID/IP/设备ID",
+                        "name": "value",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"移除成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/config": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "返回合并配置文件、环境配置、环境变量和密钥文件后的最终配置，敏感项已脱敏",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "查看生效配置",
+                "responses": {
+                    "200": {
+                        "description": "{\"env\": \"prod\", \"files\": [...], \"config\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "按订单号、用户、场次、状态查询订单，按订单ID倒序游标分页：首页不传 cursor，之后传上一页返回的 next_cursor，next_cursor 为空表示没有更多",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "查询订单列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "场次ID",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "订单状态 created/unpaid/paid/shipped/completed/cancelled/refunding/refunded",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "分页游标",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":[{\"order_num\":\"1780000000000000000\",\"user_id\":1,\"status\":\"paid\"}],\"next_cursor\":\"\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/{order_num}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "查询任意订单的详情、收货信息和状态流转记录",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "订单详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"order_num\":\"1780000000000000000\",\"user_id\":1,\"status\":\"paid\",\"history\":[{\"from\":\"created\",\"to\":\"unpaid\",\"actor\":\"system\",\"reason\":\"抢购下单\"}]}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"订单不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/{order_num}/address": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "客服联系用户后为未发货的订单（待支付、已支付）填写收货信息，下单时没有收货地址的订单补录后才能发货",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "补录订单收货信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "收货信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "city": {
+                                    "type": "string"
+                                },
+                                "detail": {
+                                    "type": "string"
+                                },
+                                "district": {
+                                    "type": "string"
+                                },
+                                "phone": {
+                                    "type": "string"
+                                },
+                                "province": {
+                                    "type": "string"
+                                },
+                                "receiver": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"收货地址已更新\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"订单已发货或已关闭，不能修改收货地址\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/{order_num}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "后台为已支付、已发货或已完成的订单发起全额或部分退款，amount 为空时退还全部可退金额。全额退款后场次仍在进行中时库存放回售卖（order.refund_restock）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "订单退款",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "退款金额（元）和原因",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "amount": {
+                                    "type": "number"
+                                },
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"refund_no\":\"1780000000000000002\",\"amount\":99.00,\"status\":\"succeeded\"}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/orders/{order_num}/ship": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "已支付订单发货，记录物流公司和物流单号，订单变为 shipped。订单没有收货地址时不能发货，需要先由用户选择或后台补录收货地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "订单发货",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "物流公司和物流单号",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "carrier": {
+                                    "type": "string"
+                                },
+                                "tracking_no": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"发货成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"未支付的订单不能变更为已发货\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/payments/{payment_no}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "订单取消或已由其他支付单支付后才到账的支付单会记为待退款，由后台全额退还，不改变订单状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "退还待退款的支付单",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "支付单号",
+                        "name": "payment_no",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "退款原因",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"refund_no\":\"1780000000000000003\",\"amount\":99.00,\"status\":\"succeeded\"}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "返回当前登录用户的角色及其权限列表，供后台前端渲染菜单",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "当前角色权限",
+                "responses": {
+                    "200": {
+                        "description": "{\"role\": \"operator\", \"permissions\": [\"product:manage\", ...]}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/products": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "分页查询商品，可按名称模糊搜索",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "后台商品列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "商品名称关键字",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...], \"total\": 10, \"page\": 1, \"size\": 20}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "创建商品基础信息，秒杀价、库存和时间在场次中设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "创建商品",
+                "parameters": [
+                    {
+                        "description": "商品信息",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ProductInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"创建成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/products/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "查询商品基础信息",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "后台商品详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "只修改传入的字段",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "修改商品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要修改的字段",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ProductInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"修改成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除商品，存在未结束的秒杀场次时不能删除（返回 409）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "删除商品",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"删除成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/sessions/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "只修改传入的字段；场次进行中不能修改商品、库存、时间和限购（返回 409），请使用库存调整接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "修改秒杀场次",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "场次ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "要修改的字段",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.SessionInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"修改成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\": \"场次进行中，不能修改库存、时间和限购，请使用库存调整\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "删除场次并清理 Redis，场次进行中不能删除（返回 409）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "删除秒杀场次",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "场次ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"删除成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/sessions/{id}/stock": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "增加或减少场次库存，MySQL 和 Redis 同步调整，场次进行中也可使用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "调整场次库存",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "场次ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "delta: 增减数量，负数为减少",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "delta": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"调整成功\", \"data\": {...}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "分页查询用户，可按用户名/手机号前缀、状态、角色过滤",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "查询用户列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户名或手机号前缀",
+                        "name": "keyword",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "状态 1:正常 2:禁用",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "角色 user/support/operator/admin",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...], \"total\": 100, \"page\": 1, \"size\": 20}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "修改用户角色，用户需要重新登录后新角色才生效（旧会话立即失效）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "分配角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "role: user/support/operator/admin",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "role": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"修改成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "禁用后该用户所有会话立即失效，且无法再登录或刷新令牌",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理模块"
+                ],
+                "summary": "禁用/启用用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "status: 1 正常，2 禁用",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "status": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"修改成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/api/login": {
             "post": {
-                "description": "用户登录获取 Token",
+                "description": "用户登录获取 Token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "登录参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "password": {
+                                    "type": "string"
+                                },
+                                "username": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"token\": \"eyJ...\", \"access_token\": \"eyJ...\", \"refresh_token\": \"...\", \"expires_in\": 900, \"message\": \"登录成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "注销当前会话；all=true 时注销该用户在所有设备上的会话",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "用户登出",
+                "parameters": [
+                    {
+                        "description": "登出参数",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "all": {
+                                    "type": "boolean"
+                                },
+                                "refresh_token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\": \"登出成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "按创建时间倒序查询自己的订单，游标分页：首页不传 cursor，之后传上一页返回的 next_cursor，next_cursor 为空表示没有更多",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "我的订单",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单状态 created/unpaid/paid/shipped/completed/cancelled/refunding/refunded",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "分页游标",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...], \"next_cursor\": \"MTcwMDAwMDAwMDAwMDAwMDoxMjM\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\":\"无效的分页游标\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{order_num}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "查询自己的订单详情、商品信息和状态流转记录。status: created/unpaid/paid/shipped/completed/cancelled/refunding/refunded",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "订单详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"order_num\":\"1780000000000000000\",\"status\":\"paid\",\"history\":[{\"from\":\"created\",\"to\":\"unpaid\",\"actor\":\"system\",\"reason\":\"抢购下单\"}]}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"订单不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{order_num}/address": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "为未发货的订单（待支付、已支付）选择地址簿中的收货地址，下单时没有收货地址的订单需要先补充才能发货",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "修改订单收货地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "收货地址ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "address_id": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"收货地址已更新\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"收货地址不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"订单已发货或已关闭，不能修改收货地址\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{order_num}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "取消未支付订单并归还库存，已取消的订单重复取消返回成功",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "取消订单",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"订单已取消\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"订单不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"已支付的订单不能变更为已取消\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{order_num}/pay": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "为未支付订单创建支付单，返回支付链接。同一渠道已有待支付的支付单时直接返回该支付单",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "发起支付",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "支付渠道（默认取配置，本地可用 mock）",
+                        "name": "provider",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"payment_no\":\"1780000000000000001\",\"order_num\":\"1780000000000000000\",\"provider\":\"mock\",\"amount\":99,\"pay_url\":\"/api/payments/mock/1780000000000000001/pay\",\"expire_at\":1700000900}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"订单不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"订单已支付\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/orders/{order_num}/refund": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "对已支付、已发货或已完成的订单申请退款，amount 为空时退还全部可退金额。退款处理期间订单为 refunding，不能再次申请",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "订单模块"
+                ],
+                "summary": "申请退款",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "订单号",
+                        "name": "order_num",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "退款金额（元，最多两位小数）和原因",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "amount": {
+                                    "type": "number"
+                                },
+                                "reason": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"refund_no\":\"1780000000000000002\",\"amount\":99.00,\"status\":\"succeeded\"}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "{\"error\":\"退款金额无效，不能超过可退金额\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "{\"error\":\"未支付的订单不能变更为退款中\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/payments/callback/{provider}": {
+            "post": {
+                "description": "由支付渠道调用，请求头携带时间戳和 HMAC 签名。处理成功（包括重复回调）返回 SUCCESS，其他响应渠道会重试",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,31 +1962,198 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户模块"
+                    "支付模块"
                 ],
-                "summary": "用户登录",
+                "summary": "支付结果回调",
                 "parameters": [
                     {
-                        "description": "登录参数",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "支付渠道",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "回调时间戳（unix 秒）",
+                        "name": "X-Pay-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256(secret, timestamp + ",
+                        "name": "X-Pay-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"code\":\"SUCCESS\"}",
                         "schema": {
                             "type": "object",
-                            "properties": {
-                                "password": {
-                                    "type": "string"
-                                },
-                                "username": {
-                                    "type": "string"
-                                }
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "{\"code\":\"FAIL\",\"message\":\"回调签名校验失败\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/payments/mock/{payment_no}/pay": {
+            "post": {
+                "description": "模拟用户在渠道完成支付，服务端构造签名回调并按正常流程处理。未启用模拟支付时返回 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "支付模块"
+                ],
+                "summary": "模拟支付（仅开发环境）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "支付单号",
+                        "name": "payment_no",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"message\":\"支付成功\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"支付单不存在\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/payments/refund-callback/{provider}": {
+            "post": {
+                "description": "由支付渠道调用，签名方式与支付回调相同。处理成功（包括重复回调）返回 SUCCESS",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "支付模块"
+                ],
+                "summary": "退款结果回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "支付渠道",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "回调时间戳（unix 秒）",
+                        "name": "X-Pay-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256(secret, timestamp + ",
+                        "name": "X-Pay-Signature",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"code\":\"SUCCESS\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/products": {
+            "get": {
+                "description": "查询有未结束秒杀场次的商品，包含原价、秒杀价、场次时间和实时剩余库存；server_time 为服务器毫秒时间戳，用于客户端倒计时校准",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品模块"
+                ],
+                "summary": "在售商品列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码，默认 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页条数，默认 20，最大 100",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\": [...], \"total\": 10, \"page\": 1, \"size\": 20, \"server_time\": 1700000000000}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
+                }
+            }
+        },
+        "/api/products/{id}": {
+            "get": {
+                "description": "查询商品信息及未结束的秒杀场次，剩余库存实时读取",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "商品模块"
+                ],
+                "summary": "商品详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "商品ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"token\": \"eyJ...\", \"msg\": \"登录成功\"}",
+                        "description": "{\"data\": {...}, \"server_time\": 1700000000000}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"商品不存在\"}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -125,15 +2229,175 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "商品ID",
-                        "name": "product_id",
+                        "description": "秒杀场次ID",
+                        "name": "session_id",
                         "in": "formData",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "收货地址ID，不传或不属于当前用户时使用默认地址",
+                        "name": "address_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备ID（风控使用）",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"code\":0,\"message\":\"抢购成功！正在生成订单...\",\"order_num\":\"1780000000000000000\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "{\"error\":\"请求存在风险，已被拦截\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "{\"error\":\"请求过于频繁，请稍后再试\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "{\"error\":\"排队人数过多，请稍后再试\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/seckill/events": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Server-Sent Events 长连接，订单创建成功或失败时推送 order 事件，内容与抢购结果接口相同并带有 session_id。浏览器 EventSource 不能设置请求头，可以通过 access_token 查询参数传递 Token。断线重连时浏览器会自动携带 Last-Event-ID 补发期间的事件；首次连接传 last_event_id=0 可以获取最近保留的全部事件",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "秒杀模块"
+                ],
+                "summary": "订单结果实时推送",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access Token（不能设置请求头时使用）",
+                        "name": "access_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "最后收到的事件ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "最后收到的事件ID（同 Last-Event-ID）",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "id: 1700000000000-0\\nevent: order\\ndata: {\"session_id\":1,\"order_num\":\"...\",\"status\":\"created\",\"updated_at\":1700000000}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/seckill/result": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "秒杀接口返回后订单异步创建，客户端轮询该接口获取最终结果。status: queued 排队中 / created 订单已创建 / failed 下单失败",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "秒杀模块"
+                ],
+                "summary": "查询抢购结果",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "秒杀场次ID",
+                        "name": "session_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"data\":{\"order_num\":\"1780000000000000000\",\"status\":\"created\",\"updated_at\":1700000000}}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "{\"error\":\"没有该场次的抢购记录\"}",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/token/refresh": {
+            "post": {
+                "description": "用 Refresh Token 换取新的 Access Token 和 Refresh Token，旧 Refresh Token 立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "刷新令牌",
+                "parameters": [
+                    {
+                        "description": "刷新参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "refresh_token": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "{\"code\":0,\"msg\":\"抢购成功\"}",
+                        "description": "{\"access_token\": \"eyJ...\", \"refresh_token\": \"...\", \"expires_in\": 900}",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -143,6 +2407,65 @@ const docTemplate = `{
             }
         }
     },
+    "definitions": {
+        "service.ActivityInput": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ProductInput": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.SessionInput": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string"
+                },
+                "per_user_limit": {
+                    "type": "integer"
+                },
+                "product_id": {
+                    "type": "integer"
+                },
+                "seckill_price": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
     "securityDefinitions": {
         "Bearer": {
             "type": "apiKey",
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"seckill/internal/model"
+	"seckill/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddressController 负责处理收货地址相关请求
+type AddressController struct{}
+
+// addressForm 创建/修改地址的请求参数
+type addressForm struct {
+	Receiver  string `json:"receiver" binding:"required,max=50"`
+	Phone     string `json:"phone" binding:"required,max=20"`
+	Province  string `json:"province" binding:"required,max=50"`
+	City      string `json:"city" binding:"required,max=50"`
+	District  string `json:"district" binding:"max=50"`
+	Detail    string `json:"detail" binding:"required,max=255"`
+	IsDefault bool   `json:"is_default"`
+}
+
+func (f *addressForm) input() *service.AddressInput {
+	return &service.AddressInput{
+		AddressSnapshot: model.AddressSnapshot{
+			Receiver: f.Receiver,
+			Phone:    f.Phone,
+			Province: f.Province,
+			City:     f.City,
+			District: f.District,
+			Detail:   f.Detail,
+		},
+		IsDefault: f.IsDefault,
+	}
+}
+
+// List 收货地址列表
+// @Summary 收货地址列表
+// @Description 默认地址排在最前
+// @Tags 收货地址
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "{"data":[{"id":1,"receiver":"张三","phone":"13800000000","province":"浙江省","city":"杭州市","district":"西湖区","detail":"文三路 1 号","is_default":true}]}"
+// @Router /api/addresses [get]
+func (ac *AddressController) List(c *gin.Context) {
+	addresses, err := service.ListAddresses(uint(c.GetInt("uid")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": addresses})
+}
+
+// Create 添加收货地址
+// @Summary 添加收货地址
+// @Description 第一个地址自动设为默认地址，每人最多 20 个
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body object{receiver=string,phone=string,province=string,city=string,district=string,detail=string,is_default=bool} true "收货地址"
+// @Success 200 {object} map[string]interface{} "{"data":{"id":1,"receiver":"张三","is_default":true}}"
+// @Router /api/addresses [post]
+func (ac *AddressController) Create(c *gin.Context) {
+	var form addressForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address, err := service.CreateAddress(uint(c.GetInt("uid")), form.input())
+	if err != nil {
+		c.JSON(addressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// Update 修改收货地址
+// @Summary 修改收货地址
+// @Description is_default 为 true 时同时设为默认地址
+// @Tags 收货地址
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Param request body object{receiver=string,phone=string,province=string,city=string,district=string,detail=string,is_default=bool} true "收货地址"
+// @Success 200 {object} map[string]interface{} "{"data":{"id":1,"receiver":"张三","is_default":true}}"
+// @Router /api/addresses/{id} [put]
+func (ac *AddressController) Update(c *gin.Context) {
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+	var form addressForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	address, err := service.UpdateAddress(uint(c.GetInt("uid")), id, form.input())
+	if err != nil {
+		c.JSON(addressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": address})
+}
+
+// Delete 删除收货地址
+// @Summary 删除收货地址
+// @Description 删除默认地址时最近添加的地址成为默认地址，已下单的订单不受影响
+// @Tags 收货地址
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Success 200 {object} map[string]interface{} "{"message":"删除成功"}"
+// @Router /api/addresses/{id} [delete]
+func (ac *AddressController) Delete(c *gin.Context) {
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+	if err := service.DeleteAddress(uint(c.GetInt("uid")), id); err != nil {
+		c.JSON(addressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// SetDefault 设为默认地址
+// @Summary 设为默认地址
+// @Tags 收货地址
+// @Produce json
+// @Security Bearer
+// @Param id path int true "地址ID"
+// @Success 200 {object} map[string]interface{} "{"message":"设置成功"}"
+// @Router /api/addresses/{id}/default [put]
+func (ac *AddressController) SetDefault(c *gin.Context) {
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+	if err := service.SetDefaultAddress(uint(c.GetInt("uid")), id); err != nil {
+		c.JSON(addressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "设置成功"})
+}
+
+// parseAddressID 解析路径中的地址ID，失败时已写入响应
+func parseAddressID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "地址ID格式错误"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// addressErrStatus 地址错误对应的 HTTP 状态码
+func addressErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAddressNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTooManyAddress):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
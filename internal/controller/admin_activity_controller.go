@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"seckill/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListActivities 秒杀活动列表
+// @Summary 秒杀活动列表
+// @Description 分页查询秒杀活动
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码，默认 1"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data": [...], "total": 10, "page": 1, "size": 20}"
+// @Router /api/admin/activities [get]
+func (ac *AdminController) ListActivities(c *gin.Context) {
+	var form struct {
+		Page int `form:"page" binding:"omitempty,min=1"`
+		Size int `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Page == 0 {
+		form.Page = 1
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	activities, total, err := service.ListActivities(form.Page, form.Size)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": activities, "total": total, "page": form.Page, "size": form.Size})
+}
+
+// GetActivity 秒杀活动详情
+// @Summary 秒杀活动详情
+// @Description 查询活动及其全部场次，包含 MySQL 库存和 Redis 剩余可抢库存
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param id path int true "活动ID"
+// @Success 200 {object} map[string]interface{} "{"data": {...}}"
+// @Router /api/admin/activities/{id} [get]
+func (ac *AdminController) GetActivity(c *gin.Context) {
+	id, ok := pathID(c, "活动ID格式错误")
+	if !ok {
+		return
+	}
+	activity, err := service.GetActivity(id)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": activity})
+}
+
+// CreateActivity 创建秒杀活动
+// @Summary 创建秒杀活动
+// @Description 创建活动，之后在活动下添加场次
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body service.ActivityInput true "活动信息"
+// @Success 200 {object} map[string]interface{} "{"message": "创建成功", "data": {...}}"
+// @Router /api/admin/activities [post]
+func (ac *AdminController) CreateActivity(c *gin.Context) {
+	var form service.ActivityInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	activity, err := service.CreateActivity(form)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "创建成功", "data": activity})
+}
+
+// UpdateActivity 修改秒杀活动
+// @Summary 修改秒杀活动
+// @Description 修改活动名称和描述
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "活动ID"
+// @Param request body service.ActivityInput true "要修改的字段"
+// @Success 200 {object} map[string]interface{} "{"message": "修改成功", "data": {...}}"
+// @Router /api/admin/activities/{id} [put]
+func (ac *AdminController) UpdateActivity(c *gin.Context) {
+	id, ok := pathID(c, "活动ID格式错误")
+	if !ok {
+		return
+	}
+	var form service.ActivityInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	activity, err := service.UpdateActivity(id, form)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "data": activity})
+}
+
+// DeleteActivity 删除秒杀活动
+// @Summary 删除秒杀活动
+// @Description 删除活动及其全部场次，有进行中的场次时不能删除（返回 409）
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param id path int true "活动ID"
+// @Success 200 {object} map[string]interface{} "{"message": "删除成功"}"
+// @Router /api/admin/activities/{id} [delete]
+func (ac *AdminController) DeleteActivity(c *gin.Context) {
+	id, ok := pathID(c, "活动ID格式错误")
+	if !ok {
+		return
+	}
+	if err := service.DeleteActivity(id); err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// CreateSession 创建秒杀场次
+// @Summary 创建秒杀场次
+// @Description 在活动下创建场次，并把库存、时间和限购写入 Redis；时间格式 RFC3339
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "活动ID"
+// @Param request body service.SessionInput true "场次信息"
+// @Success 200 {object} map[string]interface{} "{"message": "创建成功", "data": {...}}"
+// @Router /api/admin/activities/{id}/sessions [post]
+func (ac *AdminController) CreateSession(c *gin.Context) {
+	id, ok := pathID(c, "活动ID格式错误")
+	if !ok {
+		return
+	}
+	var form service.SessionInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, err := service.CreateSession(id, form)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "创建成功", "data": session})
+}
+
+// UpdateSession 修改秒杀场次
+// @Summary 修改秒杀场次
+// @Description 只修改传入的字段；场次进行中不能修改商品、库存、时间和限购（返回 409），请使用库存调整接口
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场次ID"
+// @Param request body service.SessionInput true "要修改的字段"
+// @Success 200 {object} map[string]interface{} "{"message": "修改成功", "data": {...}}"
+// @Failure 409 {object} map[string]interface{} "{"error": "场次进行中，不能修改库存、时间和限购，请使用库存调整"}"
+// @Router /api/admin/sessions/{id} [put]
+func (ac *AdminController) UpdateSession(c *gin.Context) {
+	id, ok := pathID(c, "场次ID格式错误")
+	if !ok {
+		return
+	}
+	var form service.SessionInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, err := service.UpdateSession(id, form)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "data": session})
+}
+
+// AdjustSessionStock 调整场次库存
+// @Summary 调整场次库存
+// @Description 增加或减少场次库存，MySQL 和 Redis 同步调整，场次进行中也可使用
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场次ID"
+// @Param request body object{delta=int} true "delta: 增减数量，负数为减少"
+// @Success 200 {object} map[string]interface{} "{"message": "调整成功", "data": {...}}"
+// @Router /api/admin/sessions/{id}/stock [post]
+func (ac *AdminController) AdjustSessionStock(c *gin.Context) {
+	id, ok := pathID(c, "场次ID格式错误")
+	if !ok {
+		return
+	}
+	var form struct {
+		Delta int `json:"delta" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	session, err := service.AdjustSessionStock(id, form.Delta)
+	if err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "调整成功", "data": session})
+}
+
+// DeleteSession 删除秒杀场次
+// @Summary 删除秒杀场次
+// @Description 删除场次并清理 Redis，场次进行中不能删除（返回 409）
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param id path int true "场次ID"
+// @Success 200 {object} map[string]interface{} "{"message": "删除成功"}"
+// @Router /api/admin/sessions/{id} [delete]
+func (ac *AdminController) DeleteSession(c *gin.Context) {
+	id, ok := pathID(c, "场次ID格式错误")
+	if !ok {
+		return
+	}
+	if err := service.DeleteSession(id); err != nil {
+		c.JSON(activityErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// pathID 解析路径中的 :id，失败时直接返回 400
+func pathID(c *gin.Context, msg string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// activityErrStatus 活动/场次管理错误对应的状态码
+func activityErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrActivityNotFound),
+		errors.Is(err, service.ErrSessionNotFound),
+		errors.Is(err, service.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSessionOnSale), errors.Is(err, service.ErrSessionArchived):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidActivity), errors.Is(err, service.ErrInvalidSession):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/internal/service"
+	"seckill/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController 负责系统运维和用户管理相关的后台接口
+type AdminController struct{}
+
+// Config 查看当前生效配置
+// @Summary 查看生效配置
+// @Description 返回合并配置文件、环境配置、环境变量和密钥文件后的最终配置，敏感项已脱敏
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "{"env": "prod", "files": [...], "config": {...}}"
+// @Router /api/admin/config [get]
+func (ac *AdminController) Config(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"env":    config.Env(),
+		"files":  config.Files(),
+		"config": config.Effective(),
+	})
+}
+
+// ListBlacklist 查询黑名单
+// @Summary 查询黑名单
+// @Description 按类型列出风控黑名单
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param type query string true "黑名单类型 user/ip/device"
+// @Success 200 {object} map[string]interface{} "{"data": [...]}"
+// @Router /api/admin/blacklist [get]
+func (ac *AdminController) ListBlacklist(c *gin.Context) {
+	entries, err := service.ListBlacklist(c.Query("type"))
+	if err != nil {
+		c.JSON(blacklistErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entries})
+}
+
+// AddBlacklist 添加黑名单
+// @Summary 添加黑名单
+// @Description 将用户ID/IP/设备ID加入风控黑名单，可设置过期时间
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body object{type=string,value=string,reason=string,ttl=int} true "type: user/ip/device，ttl: 过期秒数（0 为永久）"
+// @Success 200 {object} map[string]interface{} "{"message": "添加成功"}"
+// @Router /api/admin/blacklist [post]
+func (ac *AdminController) AddBlacklist(c *gin.Context) {
+	var form struct {
+		Type   string `json:"type" binding:"required"`
+		Value  string `json:"value" binding:"required"`
+		Reason string `json:"reason"`
+		TTL    int    `json:"ttl" binding:"gte=0"` // 过期秒数，0 为永久
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ttl := time.Duration(form.TTL) * time.Second
+	if err := service.AddBlacklist(form.Type, form.Value, form.Reason, ttl); err != nil {
+		c.JSON(blacklistErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "添加成功"})
+}
+
+// RemoveBlacklist 移除黑名单
+// @Summary 移除黑名单
+// @Description 将用户ID/IP/设备ID移出风控黑名单
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param type path string true "黑名单类型 user/ip/device"
+// @Param value path string true "用户ID/IP/设备ID"
+// @Success 200 {object} map[string]interface{} "{"message": "移除成功"}"
+// @Router /api/admin/blacklist/{type}/{value} [delete]
+func (ac *AdminController) RemoveBlacklist(c *gin.Context) {
+	if err := service.RemoveBlacklist(c.Param("type"), c.Param("value")); err != nil {
+		c.JSON(blacklistErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// blacklistErrStatus 黑名单参数错误返回 400，其余为 500
+func blacklistErrStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidBlacklistType) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Permissions 当前后台用户的角色和权限
+// @Summary 当前角色权限
+// @Description 返回当前登录用户的角色及其权限列表，供后台前端渲染菜单
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]interface{} "{"role": "operator", "permissions": ["product:manage", ...]}"
+// @Router /api/admin/permissions [get]
+func (ac *AdminController) Permissions(c *gin.Context) {
+	role := c.GetString("role")
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": model.RolePermissions(role),
+	})
+}
+
+// ListUsers 查询用户列表
+// @Summary 查询用户列表
+// @Description 分页查询用户，可按用户名/手机号前缀、状态、角色过滤
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param keyword query string false "用户名或手机号前缀"
+// @Param status query int false "状态 1:正常 2:禁用"
+// @Param role query string false "角色 user/support/operator/admin"
+// @Param page query int false "页码，默认 1"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data": [...], "total": 100, "page": 1, "size": 20}"
+// @Router /api/admin/users [get]
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	var form struct {
+		Keyword string `form:"keyword"`
+		Status  int    `form:"status" binding:"omitempty,oneof=1 2"`
+		Role    string `form:"role"`
+		Page    int    `form:"page" binding:"omitempty,min=1"`
+		Size    int    `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Page == 0 {
+		form.Page = 1
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	users, total, err := service.ListUsers(service.UserQuery{
+		Keyword: form.Keyword,
+		Status:  form.Status,
+		Role:    form.Role,
+		Page:    form.Page,
+		Size:    form.Size,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": users, "total": total, "page": form.Page, "size": form.Size})
+}
+
+// SetUserStatus 禁用/启用用户
+// @Summary 禁用/启用用户
+// @Description 禁用后该用户所有会话立即失效，且无法再登录或刷新令牌
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param request body object{status=int} true "status: 1 正常，2 禁用"
+// @Success 200 {object} map[string]interface{} "{"message": "修改成功"}"
+// @Router /api/admin/users/{id}/status [put]
+func (ac *AdminController) SetUserStatus(c *gin.Context) {
+	uid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户ID格式错误"})
+		return
+	}
+	var form struct {
+		Status int `json:"status" binding:"required,oneof=1 2"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.SetUserStatus(uint(c.GetInt("uid")), uint(uid), form.Status); err != nil {
+		c.JSON(userErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功"})
+}
+
+// SetUserRole 分配角色
+// @Summary 分配角色
+// @Description 修改用户角色，用户需要重新登录后新角色才生效（旧会话立即失效）
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "用户ID"
+// @Param request body object{role=string} true "role: user/support/operator/admin"
+// @Success 200 {object} map[string]interface{} "{"message": "修改成功"}"
+// @Router /api/admin/users/{id}/role [put]
+func (ac *AdminController) SetUserRole(c *gin.Context) {
+	uid, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户ID格式错误"})
+		return
+	}
+	var form struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := service.SetUserRole(uint(c.GetInt("uid")), uint(uid), form.Role); err != nil {
+		c.JSON(userErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功"})
+}
+
+// userErrStatus 用户管理错误对应的状态码
+func userErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrModifySelf):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
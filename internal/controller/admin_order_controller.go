@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"seckill/internal/model"
+	"seckill/internal/service"
+	"seckill/pkg/money"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundOrder 订单退款
+// @Summary 订单退款
+// @Description 后台为已支付、已发货或已完成的订单发起全额或部分退款，amount 为空时退还全部可退金额。全额退款后场次仍在进行中时库存放回售卖（order.refund_restock）
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param request body object{amount=number,reason=string} true "退款金额（元）和原因"
+// @Success 200 {object} map[string]interface{} "{"data":{"refund_no":"1780000000000000002","amount":99.00,"status":"succeeded"}}"
+// @Router /api/admin/orders/{order_num}/refund [post]
+func (ac *AdminController) RefundOrder(c *gin.Context) {
+	var form struct {
+		Amount money.Money `json:"amount"`
+		Reason string      `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	refund, err := service.AdminRefund(uint(c.GetInt("uid")), c.Param("order_num"), form.Amount, form.Reason)
+	if err != nil {
+		c.JSON(refundErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": refund})
+}
+
+// RefundPayment 退还待退款的支付单
+// @Summary 退还待退款的支付单
+// @Description 订单取消或已由其他支付单支付后才到账的支付单会记为待退款，由后台全额退还，不改变订单状态
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param payment_no path string true "支付单号"
+// @Param request body object{reason=string} true "退款原因"
+// @Success 200 {object} map[string]interface{} "{"data":{"refund_no":"1780000000000000003","amount":99.00,"status":"succeeded"}}"
+// @Router /api/admin/payments/{payment_no}/refund [post]
+func (ac *AdminController) RefundPayment(c *gin.Context) {
+	var form struct {
+		Reason string `json:"reason" binding:"required,max=255"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	refund, err := service.RefundPayment(uint(c.GetInt("uid")), c.Param("payment_no"), form.Reason)
+	if err != nil {
+		c.JSON(refundErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": refund})
+}
+
+// ShipOrder 订单发货
+// @Summary 订单发货
+// @Description 已支付订单发货，记录物流公司和物流单号，订单变为 shipped。订单没有收货地址时不能发货，需要先由用户选择或后台补录收货地址
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param request body object{carrier=string,tracking_no=string} true "物流公司和物流单号"
+// @Success 200 {object} map[string]interface{} "{"message":"发货成功"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"未支付的订单不能变更为已发货"}"
+// @Router /api/admin/orders/{order_num}/ship [post]
+func (ac *AdminController) ShipOrder(c *gin.Context) {
+	var form struct {
+		Carrier    string `json:"carrier" binding:"required,max=50"`
+		TrackingNo string `json:"tracking_no" binding:"required,max=64"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := service.ShipOrder(uint(c.GetInt("uid")), c.Param("order_num"), form.Carrier, form.TrackingNo)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrNoShippingAddress), errors.Is(err, model.ErrIllegalTransition),
+			errors.Is(err, service.ErrOrderStatusChanged):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "发货成功"})
+}
+
+// SetOrderAddress 补录订单收货信息
+// @Summary 补录订单收货信息
+// @Description 客服联系用户后为未发货的订单（待支付、已支付）填写收货信息，下单时没有收货地址的订单补录后才能发货
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param request body object{receiver=string,phone=string,province=string,city=string,district=string,detail=string} true "收货信息"
+// @Success 200 {object} map[string]interface{} "{"message":"收货地址已更新"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"订单已发货或已关闭，不能修改收货地址"}"
+// @Router /api/admin/orders/{order_num}/address [put]
+func (ac *AdminController) SetOrderAddress(c *gin.Context) {
+	var form addressForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := service.AdminSetOrderAddress(uint(c.GetInt("uid")), c.Param("order_num"), form.input().AddressSnapshot)
+	if err != nil {
+		c.JSON(orderAddressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "收货地址已更新"})
+}
+
+// ListOrders 查询订单列表
+// @Summary 查询订单列表
+// @Description 按订单号、用户、场次、状态查询订单，按订单ID倒序游标分页：首页不传 cursor，之后传上一页返回的 next_cursor，next_cursor 为空表示没有更多
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param order_num query string false "订单号"
+// @Param user_id query int false "用户ID"
+// @Param session_id query int false "场次ID"
+// @Param status query string false "订单状态 created/unpaid/paid/shipped/completed/cancelled/refunding/refunded"
+// @Param cursor query string false "分页游标"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data":[{"order_num":"1780000000000000000","user_id":1,"status":"paid"}],"next_cursor":""}"
+// @Router /api/admin/orders [get]
+func (ac *AdminController) ListOrders(c *gin.Context) {
+	var form struct {
+		OrderNum  string `form:"order_num"`
+		UserID    uint   `form:"user_id"`
+		SessionID uint   `form:"session_id"`
+		Status    string `form:"status"`
+		Cursor    string `form:"cursor"`
+		Size      int    `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	orders, next, err := service.AdminListOrders(service.AdminOrderQuery{
+		OrderNum:  form.OrderNum,
+		UserID:    form.UserID,
+		SessionID: form.SessionID,
+		Status:    form.Status,
+		Cursor:    form.Cursor,
+		Size:      form.Size,
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidOrderStatus) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        orders,
+		"next_cursor": next,
+	})
+}
+
+// GetOrder 订单详情
+// @Summary 订单详情
+// @Description 查询任意订单的详情、收货信息和状态流转记录
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Success 200 {object} map[string]interface{} "{"data":{"order_num":"1780000000000000000","user_id":1,"status":"paid","history":[{"from":"created","to":"unpaid","actor":"system","reason":"抢购下单"}]}}"
+// @Failure 404 {object} map[string]interface{} "{"error":"订单不存在"}"
+// @Router /api/admin/orders/{order_num} [get]
+func (ac *AdminController) GetOrder(c *gin.Context) {
+	detail, err := service.AdminGetOrderDetail(c.Param("order_num"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
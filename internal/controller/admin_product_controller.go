@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"seckill/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListProducts 后台商品列表
+// @Summary 后台商品列表
+// @Description 分页查询商品，可按名称模糊搜索
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param keyword query string false "商品名称关键字"
+// @Param page query int false "页码，默认 1"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data": [...], "total": 10, "page": 1, "size": 20}"
+// @Router /api/admin/products [get]
+func (ac *AdminController) ListProducts(c *gin.Context) {
+	var form struct {
+		Keyword string `form:"keyword"`
+		Page    int    `form:"page" binding:"omitempty,min=1"`
+		Size    int    `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Page == 0 {
+		form.Page = 1
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	products, total, err := service.ListProducts(form.Keyword, form.Page, form.Size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": products, "total": total, "page": form.Page, "size": form.Size})
+}
+
+// GetProduct 后台商品详情
+// @Summary 后台商品详情
+// @Description 查询商品基础信息
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商品ID"
+// @Success 200 {object} map[string]interface{} "{"data": {...}}"
+// @Router /api/admin/products/{id} [get]
+func (ac *AdminController) GetProduct(c *gin.Context) {
+	id, ok := pathID(c, "商品ID格式错误")
+	if !ok {
+		return
+	}
+	product, err := service.GetProduct(id)
+	if err != nil {
+		c.JSON(productErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": product})
+}
+
+// CreateProduct 创建商品
+// @Summary 创建商品
+// @Description 创建商品基础信息，秒杀价、库存和时间在场次中设置
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body service.ProductInput true "商品信息"
+// @Success 200 {object} map[string]interface{} "{"message": "创建成功", "data": {...}}"
+// @Router /api/admin/products [post]
+func (ac *AdminController) CreateProduct(c *gin.Context) {
+	var form service.ProductInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	product, err := service.CreateProduct(form)
+	if err != nil {
+		c.JSON(productErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "创建成功", "data": product})
+}
+
+// UpdateProduct 修改商品
+// @Summary 修改商品
+// @Description 只修改传入的字段
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商品ID"
+// @Param request body service.ProductInput true "要修改的字段"
+// @Success 200 {object} map[string]interface{} "{"message": "修改成功", "data": {...}}"
+// @Router /api/admin/products/{id} [put]
+func (ac *AdminController) UpdateProduct(c *gin.Context) {
+	id, ok := pathID(c, "商品ID格式错误")
+	if !ok {
+		return
+	}
+	var form service.ProductInput
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	product, err := service.UpdateProduct(id, form)
+	if err != nil {
+		c.JSON(productErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "修改成功", "data": product})
+}
+
+// DeleteProduct 删除商品
+// @Summary 删除商品
+// @Description 删除商品，存在未结束的秒杀场次时不能删除（返回 409）
+// @Tags 管理模块
+// @Produce json
+// @Security Bearer
+// @Param id path int true "商品ID"
+// @Success 200 {object} map[string]interface{} "{"message": "删除成功"}"
+// @Router /api/admin/products/{id} [delete]
+func (ac *AdminController) DeleteProduct(c *gin.Context) {
+	id, ok := pathID(c, "商品ID格式错误")
+	if !ok {
+		return
+	}
+	if err := service.DeleteProduct(id); err != nil {
+		c.JSON(productErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// productErrStatus 商品管理错误对应的状态码
+func productErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrProductInUse):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidProduct):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"seckill/internal/model"
+	"seckill/internal/service"
+	"seckill/pkg/money"
+	"seckill/pkg/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderController 负责处理用户订单相关请求
+type OrderController struct{}
+
+// List 我的订单
+// @Summary 我的订单
+// @Description 按创建时间倒序查询自己的订单，游标分页：首页不传 cursor，之后传上一页返回的 next_cursor，next_cursor 为空表示没有更多
+// @Tags 订单模块
+// @Produce json
+// @Security Bearer
+// @Param status query string false "订单状态 created/unpaid/paid/shipped/completed/cancelled/refunding/refunded"
+// @Param cursor query string false "分页游标"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data": [...], "next_cursor": "MTcwMDAwMDAwMDAwMDAwMDoxMjM"}"
+// @Failure 400 {object} map[string]interface{} "{"error":"无效的分页游标"}"
+// @Router /api/orders [get]
+func (oc *OrderController) List(c *gin.Context) {
+	var form struct {
+		Status string `form:"status"`
+		Cursor string `form:"cursor"`
+		Size   int    `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	orders, next, err := service.ListUserOrders(uint(c.GetInt("uid")), form.Status, form.Cursor, form.Size)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidOrderStatus) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        orders,
+		"next_cursor": next,
+	})
+}
+
+// Get 订单详情
+// @Summary 订单详情
+// @Description 查询自己的订单详情、商品信息和状态流转记录。status: created/unpaid/paid/shipped/completed/cancelled/refunding/refunded
+// @Tags 订单模块
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Success 200 {object} map[string]interface{} "{"data":{"order_num":"1780000000000000000","status":"paid","history":[{"from":"created","to":"unpaid","actor":"system","reason":"抢购下单"}]}}"
+// @Failure 404 {object} map[string]interface{} "{"error":"订单不存在"}"
+// @Router /api/orders/{order_num} [get]
+func (oc *OrderController) Get(c *gin.Context) {
+	detail, err := service.GetOrderDetail(uint(c.GetInt("uid")), c.Param("order_num"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+// Pay 发起支付
+// @Summary 发起支付
+// @Description 为未支付订单创建支付单，返回支付链接。同一渠道已有待支付的支付单时直接返回该支付单
+// @Tags 订单模块
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param provider formData string false "支付渠道（默认取配置，本地可用 mock）"
+// @Success 200 {object} map[string]interface{} "{"data":{"payment_no":"1780000000000000001","order_num":"1780000000000000000","provider":"mock","amount":99,"pay_url":"/api/payments/mock/1780000000000000001/pay","expire_at":1700000900}}"
+// @Failure 404 {object} map[string]interface{} "{"error":"订单不存在"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"订单已支付"}"
+// @Router /api/orders/{order_num}/pay [post]
+func (oc *OrderController) Pay(c *gin.Context) {
+	view, err := service.PayOrder(uint(c.GetInt("uid")), c.Param("order_num"), c.PostForm("provider"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrOrderPaid), errors.Is(err, service.ErrOrderClosed):
+			status = http.StatusConflict
+		case errors.Is(err, payment.ErrProviderNotFound):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": view})
+}
+
+// Cancel 取消订单
+// @Summary 取消订单
+// @Description 取消未支付订单并归还库存，已取消的订单重复取消返回成功
+// @Tags 订单模块
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Success 200 {object} map[string]interface{} "{"message":"订单已取消"}"
+// @Failure 404 {object} map[string]interface{} "{"error":"订单不存在"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"已支付的订单不能变更为已取消"}"
+// @Router /api/orders/{order_num}/cancel [post]
+func (oc *OrderController) Cancel(c *gin.Context) {
+	err := service.CancelOrderByUser(uint(c.GetInt("uid")), c.Param("order_num"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, model.ErrIllegalTransition), errors.Is(err, service.ErrOrderStatusChanged):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "订单已取消"})
+}
+
+// Refund 申请退款
+// @Summary 申请退款
+// @Description 对已支付、已发货或已完成的订单申请退款，amount 为空时退还全部可退金额。退款处理期间订单为 refunding，不能再次申请
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param request body object{amount=number,reason=string} false "退款金额（元，最多两位小数）和原因"
+// @Success 200 {object} map[string]interface{} "{"data":{"refund_no":"1780000000000000002","amount":99.00,"status":"succeeded"}}"
+// @Failure 400 {object} map[string]interface{} "{"error":"退款金额无效，不能超过可退金额"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"未支付的订单不能变更为退款中"}"
+// @Router /api/orders/{order_num}/refund [post]
+func (oc *OrderController) Refund(c *gin.Context) {
+	var form struct {
+		Amount money.Money `json:"amount"`
+		Reason string      `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Reason == "" {
+		form.Reason = "用户申请退款"
+	}
+	refund, err := service.RequestRefund(uint(c.GetInt("uid")), c.Param("order_num"), form.Amount, form.Reason)
+	if err != nil {
+		c.JSON(refundErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": refund})
+}
+
+// refundErrStatus 退款错误对应的 HTTP 状态码
+func refundErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrPaymentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidRefundAmount):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrIllegalTransition), errors.Is(err, service.ErrOrderStatusChanged),
+		errors.Is(err, service.ErrNoPaidPayment), errors.Is(err, service.ErrPaymentNotRefundable),
+		errors.Is(err, service.ErrRefundInProgress):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// SetAddress 修改订单收货地址
+// @Summary 修改订单收货地址
+// @Description 为未发货的订单（待支付、已支付）选择地址簿中的收货地址，下单时没有收货地址的订单需要先补充才能发货
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param order_num path string true "订单号"
+// @Param request body object{address_id=int} true "收货地址ID"
+// @Success 200 {object} map[string]interface{} "{"message":"收货地址已更新"}"
+// @Failure 404 {object} map[string]interface{} "{"error":"收货地址不存在"}"
+// @Failure 409 {object} map[string]interface{} "{"error":"订单已发货或已关闭，不能修改收货地址"}"
+// @Router /api/orders/{order_num}/address [put]
+func (oc *OrderController) SetAddress(c *gin.Context) {
+	var form struct {
+		AddressID uint `json:"address_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := service.SetOrderAddress(uint(c.GetInt("uid")), c.Param("order_num"), form.AddressID)
+	if err != nil {
+		c.JSON(orderAddressErrStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "收货地址已更新"})
+}
+
+// orderAddressErrStatus 修改订单收货地址错误对应的 HTTP 状态码
+func orderAddressErrStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrAddressNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOrderAddressLocked):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"seckill/internal/service"
+	"seckill/pkg/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentController 负责处理支付渠道回调
+type PaymentController struct{}
+
+// Callback 支付结果回调
+// @Summary 支付结果回调
+// @Description 由支付渠道调用，请求头携带时间戳和 HMAC 签名。处理成功（包括重复回调）返回 SUCCESS，其他响应渠道会重试
+// @Tags 支付模块
+// @Accept json
+// @Produce json
+// @Param provider path string true "支付渠道"
+// @Param X-Pay-Timestamp header string true "回调时间戳（unix 秒）"
+// @Param X-Pay-Signature header string true "HMAC-SHA256(secret, timestamp + "." + body)"
+// @Success 200 {object} map[string]interface{} "{"code":"SUCCESS"}"
+// @Failure 401 {object} map[string]interface{} "{"code":"FAIL","message":"回调签名校验失败"}"
+// @Router /api/payments/callback/{provider} [post]
+func (pc *PaymentController) Callback(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "FAIL", "message": "读取请求失败"})
+		return
+	}
+	if err := service.HandlePaymentCallback(c.Param("provider"), c.Request.Header, body); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature), errors.Is(err, payment.ErrCallbackExpired):
+			status = http.StatusUnauthorized
+		case errors.Is(err, payment.ErrProviderNotFound), errors.Is(err, service.ErrPaymentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrAmountMismatch):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"code": "FAIL", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS"})
+}
+
+// MockPay 模拟支付
+// @Summary 模拟支付（仅开发环境）
+// @Description 模拟用户在渠道完成支付，服务端构造签名回调并按正常流程处理。未启用模拟支付时返回 404
+// @Tags 支付模块
+// @Produce json
+// @Param payment_no path string true "支付单号"
+// @Success 200 {object} map[string]interface{} "{"message":"支付成功"}"
+// @Failure 404 {object} map[string]interface{} "{"error":"支付单不存在"}"
+// @Router /api/payments/mock/{payment_no}/pay [post]
+func (pc *PaymentController) MockPay(c *gin.Context) {
+	if err := service.CompleteMockPayment(c.Param("payment_no")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, payment.ErrProviderNotFound) || errors.Is(err, service.ErrPaymentNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "支付成功"})
+}
+
+// RefundCallback 退款结果回调
+// @Summary 退款结果回调
+// @Description 由支付渠道调用，签名方式与支付回调相同。处理成功（包括重复回调）返回 SUCCESS
+// @Tags 支付模块
+// @Accept json
+// @Produce json
+// @Param provider path string true "支付渠道"
+// @Param X-Pay-Timestamp header string true "回调时间戳（unix 秒）"
+// @Param X-Pay-Signature header string true "HMAC-SHA256(secret, timestamp + "." + body)"
+// @Success 200 {object} map[string]interface{} "{"code":"SUCCESS"}"
+// @Router /api/payments/refund-callback/{provider} [post]
+func (pc *PaymentController) RefundCallback(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": "FAIL", "message": "读取请求失败"})
+		return
+	}
+	if err := service.HandleRefundCallback(c.Param("provider"), c.Request.Header, body); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, payment.ErrInvalidSignature), errors.Is(err, payment.ErrCallbackExpired):
+			status = http.StatusUnauthorized
+		case errors.Is(err, payment.ErrProviderNotFound), errors.Is(err, service.ErrRefundNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, service.ErrAmountMismatch):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"code": "FAIL", "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": "SUCCESS"})
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"seckill/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProductController 前台商品查询
+type ProductController struct{}
+
+// List 在售商品列表
+// @Summary 在售商品列表
+// @Description 查询有未结束秒杀场次的商品，包含原价、秒杀价、场次时间和实时剩余库存；server_time 为服务器毫秒时间戳，用于客户端倒计时校准
+// @Tags 商品模块
+// @Produce json
+// @Param page query int false "页码，默认 1"
+// @Param size query int false "每页条数，默认 20，最大 100"
+// @Success 200 {object} map[string]interface{} "{"data": [...], "total": 10, "page": 1, "size": 20, "server_time": 1700000000000}"
+// @Router /api/products [get]
+func (pc *ProductController) List(c *gin.Context) {
+	var form struct {
+		Page int `form:"page" binding:"omitempty,min=1"`
+		Size int `form:"size" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Page == 0 {
+		form.Page = 1
+	}
+	if form.Size == 0 {
+		form.Size = 20
+	}
+	products, total, err := service.ListOnSaleProducts(form.Page, form.Size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        products,
+		"total":       total,
+		"page":        form.Page,
+		"size":        form.Size,
+		"server_time": time.Now().UnixMilli(),
+	})
+}
+
+// Get 商品详情
+// @Summary 商品详情
+// @Description 查询商品信息及未结束的秒杀场次，剩余库存实时读取
+// @Tags 商品模块
+// @Produce json
+// @Param id path int true "商品ID"
+// @Success 200 {object} map[string]interface{} "{"data": {...}, "server_time": 1700000000000}"
+// @Failure 404 {object} map[string]interface{} "{"error":"商品不存在"}"
+// @Router /api/products/{id} [get]
+func (pc *ProductController) Get(c *gin.Context) {
+	id, ok := pathID(c, "商品ID格式错误")
+	if !ok {
+		return
+	}
+	product, err := service.GetProductDetail(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrProductNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": product, "server_time": time.Now().UnixMilli()})
+}
@@ -1,11 +1,16 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"seckill/internal/service"
+	"seckill/pkg/config"
 	"seckill/pkg/logger"
 	"strconv"
+	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -20,11 +25,16 @@ type SeckillController struct{}
 // @Accept x-www-form-urlencoded
 // @Produce json
 // @Security Bearer
-// @Param product_id formData int true "商品ID"
-// @Success 200 {object} map[string]interface{} "{"code":0,"msg":"抢购成功"}"
+// @Param session_id formData int true "秒杀场次ID"
+// @Param address_id formData int false "收货地址ID，不传或不属于当前用户时使用默认地址"
+// @Param X-Device-ID header string false "设备ID（风控使用）"
+// @Success 200 {object} map[string]interface{} "{"code":0,"message":"抢购成功！正在生成订单...","order_num":"1780000000000000000"}"
+// @Failure 429 {object} map[string]interface{} "{"error":"请求过于频繁，请稍后再试"}"
+// @Failure 403 {object} map[string]interface{} "{"error":"请求存在风险，已被拦截"}"
+// @Failure 503 {object} map[string]interface{} "{"error":"排队人数过多，请稍后再试"}"
 // @Router /api/seckill/buy [post]
 func (sc *SeckillController) Buy(c *gin.Context) {
-	//1、获取用户ID和商品ID
+	//1、获取用户ID和场次ID
 	//暂时模拟一个用户id
 	uid, exists := c.Get("uid")
 	if !exists {
@@ -32,25 +42,39 @@ func (sc *SeckillController) Buy(c *gin.Context) {
 		return
 	}
 	userID := uid.(int) // 断言为 int
-	//从请求参数获取商品ID
-	pidStr := c.PostForm("product_id")
-	productid, err := strconv.Atoi(pidStr)
+	//从请求参数获取场次ID
+	sidStr := c.PostForm("session_id")
+	sessionID, err := strconv.Atoi(sidStr)
 	if err != nil {
-		logger.Log.Error("获取商品ID失败", zap.Error(err))
+		logger.Log.Error("获取场次ID失败", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": "无效的商品ID",
+			"message": "无效的场次ID",
 		})
 		return
 	}
+	//收货地址（可选），归属在创建订单时校验，秒杀入口不查询 MySQL
+	var addressID uint
+	if s := c.PostForm("address_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "无效的收货地址ID",
+			})
+			return
+		}
+		addressID = uint(id)
+	}
 	//2、调用service层的秒杀逻辑
-	result, message := service.SeckillV2(userID, productid)
-	//3、返回结果
+	result, orderNum, message := service.SeckillV2(userID, sessionID, addressID)
+	//3、返回结果（订单异步创建，客户端凭订单号轮询 /api/seckill/result）
 	if result {
 		c.JSON(http.StatusOK, gin.H{
-			"success": true,
-			"message": message,
-			"code":    0,
+			"success":   true,
+			"message":   message,
+			"code":      0,
+			"order_num": orderNum,
 		})
 	} else {
 		c.JSON(http.StatusOK, gin.H{
@@ -61,3 +85,106 @@ func (sc *SeckillController) Buy(c *gin.Context) {
 	}
 
 }
+
+// Result 查询抢购结果
+// @Summary 查询抢购结果
+// @Description 秒杀接口返回后订单异步创建，客户端轮询该接口获取最终结果。status: queued 排队中 / created 订单已创建 / failed 下单失败
+// @Tags 秒杀模块
+// @Produce json
+// @Security Bearer
+// @Param session_id query int true "秒杀场次ID"
+// @Success 200 {object} map[string]interface{} "{"data":{"order_num":"1780000000000000000","status":"created","updated_at":1700000000}}"
+// @Failure 404 {object} map[string]interface{} "{"error":"没有该场次的抢购记录"}"
+// @Router /api/seckill/result [get]
+func (sc *SeckillController) Result(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Query("session_id"), 10, 64)
+	if err != nil || sessionID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的场次ID"})
+		return
+	}
+	result, err := service.GetSeckillResult(uint(c.GetInt("uid")), uint(sessionID))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNoSeckillRecord) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// Events 订单结果实时推送（SSE）
+// @Summary 订单结果实时推送
+// @Description Server-Sent Events 长连接，订单创建成功或失败时推送 order 事件，内容与抢购结果接口相同并带有 session_id。浏览器 EventSource 不能设置请求头，可以通过 access_token 查询参数传递 Token。断线重连时浏览器会自动携带 Last-Event-ID 补发期间的事件；首次连接传 last_event_id=0 可以获取最近保留的全部事件
+// @Tags 秒杀模块
+// @Produce text/event-stream
+// @Security Bearer
+// @Param access_token query string false "Access Token（不能设置请求头时使用）"
+// @Param Last-Event-ID header string false "最后收到的事件ID"
+// @Param last_event_id query string false "最后收到的事件ID（同 Last-Event-ID）"
+// @Success 200 {string} string "id: 1700000000000-0\nevent: order\ndata: {"session_id":1,"order_num":"...","status":"created","updated_at":1700000000}"
+// @Router /api/seckill/events [get]
+func (sc *SeckillController) Events(c *gin.Context) {
+	uid := uint(c.GetInt("uid"))
+	cfg := config.Get().Events
+
+	// 先订阅再补发，避免补发期间产生的事件丢失；两者重复的事件按事件ID去重
+	events, cancel := service.SubscribeOrderEvents(uid)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" {
+		missed, err := service.OrderEventsSince(uid, lastID)
+		if err != nil {
+			logger.Log.Warn("补发订单事件失败", zap.Uint("uid", uid), zap.String("last_id", lastID), zap.Error(err))
+			lastID = ""
+		}
+		for _, e := range missed {
+			writeOrderEvent(c, e)
+			lastID = e.ID
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(cfg.Heartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(cfg.MaxDuration)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-deadline.C:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case e, ok := <-events:
+			if !ok {
+				return // 推送过慢被断开，客户端重连后补发
+			}
+			if lastID != "" && !service.EventAfter(e.ID, lastID) {
+				continue
+			}
+			writeOrderEvent(c, e)
+			lastID = e.ID
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeOrderEvent 写出一条 SSE 事件
+func writeOrderEvent(c *gin.Context, e service.OrderEvent) {
+	c.Render(-1, sse.Event{Id: e.ID, Event: "order", Data: e.Data})
+}
@@ -2,8 +2,10 @@ package controller
 
 import (
 	"seckill/internal/service"
+	"seckill/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserController struct{}
@@ -42,7 +44,7 @@ func (uc *UserController) Register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body object{username=string,password=string} true "登录参数"
-// @Success 200 {object} map[string]interface{} "{"token": "eyJ...", "msg": "登录成功"}"
+// @Success 200 {object} map[string]interface{} "{"token": "eyJ...", "access_token": "eyJ...", "refresh_token": "...", "expires_in": 900, "message": "登录成功"}"
 // @Router /api/login [post]
 // Login 登录接口
 func (uc *UserController) Login(c *gin.Context) {
@@ -54,10 +56,93 @@ func (uc *UserController) Login(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	token, err := service.Login(form.Username, form.Password)
+	tokens, err := service.Login(form.Username, form.Password)
 	if err != nil {
 		c.JSON(401, gin.H{"error": "登录失败: " + err.Error()})
 		return
 	}
-	c.JSON(200, gin.H{"message": "登录成功", "token": token})
+	c.JSON(200, gin.H{
+		"message":       "登录成功",
+		"token":         tokens.AccessToken, // 兼容旧客户端
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Refresh 刷新令牌
+// @Summary 刷新令牌
+// @Description 用 Refresh Token 换取新的 Access Token 和 Refresh Token，旧 Refresh Token 立即失效
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @Param request body object{refresh_token=string} true "刷新参数"
+// @Success 200 {object} map[string]interface{} "{"access_token": "eyJ...", "refresh_token": "...", "expires_in": 900}"
+// @Router /api/token/refresh [post]
+func (uc *UserController) Refresh(c *gin.Context) {
+	var form struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := service.RefreshTokens(form.RefreshToken)
+	if err != nil {
+		c.JSON(401, gin.H{"error": "刷新失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{
+		"message":       "刷新成功",
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// Logout 用户登出
+// @Summary 用户登出
+// @Description 注销当前会话；all=true 时注销该用户在所有设备上的会话
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body object{refresh_token=string,all=bool} false "登出参数"
+// @Success 200 {object} map[string]interface{} "{"message": "登出成功"}"
+// @Router /api/logout [post]
+func (uc *UserController) Logout(c *gin.Context) {
+	var form struct {
+		RefreshToken string `json:"refresh_token"`
+		All          bool   `json:"all"`
+	}
+	// 请求体可选
+	_ = c.ShouldBindJSON(&form)
+
+	uid := uint(c.GetInt("uid"))
+	claims, _ := c.MustGet("claims").(jwt.MapClaims)
+
+	var err error
+	if form.All {
+		err = service.RevokeUserSessions(uid)
+	} else {
+		err = service.Logout(uid, claims, form.RefreshToken)
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "登出失败: " + err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "登出成功"})
+}
+
+// JWKS JWT 公钥集
+// @Summary JWT 公钥集
+// @Description 返回 access token 验签公钥（JWKS 格式），包含当前密钥和轮换中的旧密钥
+// @Tags 用户模块
+// @Produce json
+// @Success 200 {object} map[string]interface{} "{"keys": [{"kty": "OKP", "crv": "Ed25519", "kid": "jwt-2026-10", "alg": "EdDSA", "use": "sig", "x": "..."}]}"
+// @Router /.well-known/jwks.json [get]
+func (uc *UserController) JWKS(c *gin.Context) {
+	// 允许验签方缓存，轮换时新公钥需提前发布
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, utils.JWKS())
 }
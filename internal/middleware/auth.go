@@ -2,17 +2,34 @@ package middleware
 
 import (
 	"net/http"
+	"seckill/internal/model"
+	"seckill/internal/service"
+	"seckill/pkg/logger"
 	"seckill/pkg/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // JWTAuth 鉴权中间件
 func JWTAuth() gin.HandlerFunc {
+	return jwtAuth(false)
+}
+
+// JWTAuthAllowQuery 鉴权中间件，没有 Authorization 头时从 ?access_token= 读取 Token
+// 浏览器 EventSource 不能设置请求头，只用于 SSE 接口，普通接口不要使用
+func JWTAuthAllowQuery() gin.HandlerFunc {
+	return jwtAuth(true)
+}
+
+func jwtAuth(allowQuery bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. 获取 Header 中的 Authorization
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && allowQuery && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "未携带Token"})
 			return
@@ -32,11 +49,35 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 4. 将 UserID 存入 Context，供后续接口使用
 		// 注意：claims["uid"] 解析出来可能是 float64，需要转换
-		if uid, ok := claims["uid"].(float64); ok {
-			c.Set("uid", int(uid))
+		uid, ok := claims["uid"].(float64)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token无效或已过期"})
+			return
+		}
+
+		// 4. 检查是否已登出或被踢下线
+		revoked, err := service.IsTokenRevoked(uint(uid), claims)
+		if err != nil {
+			// 无法确认令牌状态时拒绝，避免已注销的令牌在 Redis 故障期间继续可用
+			logger.Log.Error("检查Token状态失败", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "系统繁忙，请稍后再试"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token已失效，请重新登录"})
+			return
+		}
+
+		// 5. 将 UserID、角色和 claims 存入 Context，供后续接口使用
+		// 升级前签发的 token 没有 role 声明，按普通用户处理
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = model.RoleUser
 		}
+		c.Set("uid", int(uid))
+		c.Set("role", role)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
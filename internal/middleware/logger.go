@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"net/url"
 	"seckill/pkg/logger"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,7 +15,7 @@ func ZapLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 		//处理请求
 		c.Next()
 		//处理完请求后记录耗时
@@ -38,3 +40,16 @@ func ZapLogger() gin.HandlerFunc {
 		}
 	}
 }
+
+// redactQuery 隐藏查询参数中的 Token（SSE 接口允许通过 access_token 传递）
+func redactQuery(raw string) string {
+	if !strings.Contains(raw, "access_token") {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+	values.Set("access_token", "redacted")
+	return values.Encode()
+}
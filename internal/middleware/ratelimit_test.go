@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"seckill/pkg/config"
+)
+
+func TestRateLimitArgs(t *testing.T) {
+	rule := config.RouteLimit{
+		User:   config.LimitRule{Rate: 1, Burst: 2},
+		IP:     config.LimitRule{Rate: 2.5},
+		Global: config.LimitRule{Rate: 1000, Burst: 2000},
+	}
+
+	tests := []struct {
+		name     string
+		uid      interface{}
+		rule     config.RouteLimit
+		wantKeys []string
+		wantArgs []interface{}
+	}{
+		{
+			name: "三个维度",
+			uid:  42,
+			rule: rule,
+			wantKeys: []string{
+				"ratelimit:{seckill_buy}:user:42",
+				"ratelimit:{seckill_buy}:ip:1.2.3.4",
+				"ratelimit:{seckill_buy}:global",
+			},
+			// 每个维度依次为 rate、burst，IP 未配置 burst 时取 ceil(2.5)
+			wantArgs: []interface{}{1.0, 2, 2.5, 3, 1000.0, 2000},
+		},
+		{
+			name: "未登录不按用户限流",
+			uid:  nil,
+			rule: rule,
+			wantKeys: []string{
+				"ratelimit:{seckill_buy}:ip:1.2.3.4",
+				"ratelimit:{seckill_buy}:global",
+			},
+			wantArgs: []interface{}{2.5, 3, 1000.0, 2000},
+		},
+		{
+			name:     "rate 为 0 的维度跳过",
+			uid:      42,
+			rule:     config.RouteLimit{IP: config.LimitRule{Rate: 0, Burst: 10}, Global: config.LimitRule{Rate: 0.5}},
+			wantKeys: []string{"ratelimit:{seckill_buy}:global"},
+			wantArgs: []interface{}{0.5, 1},
+		},
+		{
+			name: "全部不限流",
+			uid:  42,
+			rule: config.RouteLimit{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, args := rateLimitArgs("seckill_buy", tt.uid, "1.2.3.4", tt.rule)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+			// 所有 key 必须在同一个哈希槽，否则 Redis Cluster 拒绝执行脚本
+			for _, key := range keys {
+				if !strings.HasPrefix(key, "ratelimit:{seckill_buy}:") {
+					t.Errorf("key %q 缺少路由哈希标签", key)
+				}
+			}
+			// 脚本按 ARGV[2i-1]、ARGV[2i] 读取第 i 个维度的参数
+			if len(args) != 2*len(keys) {
+				t.Errorf("len(args) = %d, want %d", len(args), 2*len(keys))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"seckill/internal/model"
+	"seckill/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequireRole 要求当前用户属于指定角色之一
+// 需要挂在 JWTAuth 之后，角色取自 token 的 role 声明
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		forbid(c, "role", role)
+	}
+}
+
+// RequirePermission 要求当前用户的角色拥有指定权限
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if !model.RoleHasPermission(role, perm) {
+			forbid(c, perm, role)
+			return
+		}
+		c.Next()
+	}
+}
+
+// forbid 记录越权访问并返回 403
+func forbid(c *gin.Context, required, role string) {
+	logger.Log.Warn("越权访问被拒绝",
+		zap.Any("uid", c.Value("uid")),
+		zap.String("role", role),
+		zap.String("required", required),
+		zap.String("path", c.FullPath()),
+	)
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "没有权限访问"})
+}
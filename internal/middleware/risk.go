@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"seckill/internal/service"
+	"seckill/pkg/breaker"
+	"seckill/pkg/config"
+	"seckill/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RiskControl 风控中间件，拦截黑名单和高风险请求
+// 需要挂在 JWTAuth 之后、秒杀接口之前
+func RiskControl() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get().Risk
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+		uid, exists := c.Get("uid")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		// 1. 收集风控信号
+		req := service.RiskRequest{
+			UserID:   uid.(int),
+			IP:       c.ClientIP(),
+			DeviceID: c.GetHeader(cfg.DeviceHeader),
+			Headers:  make(map[string]string, len(cfg.RequiredHeaders)),
+		}
+		for _, name := range cfg.RequiredHeaders {
+			req.Headers[name] = c.GetHeader(name)
+		}
+
+		// 2. 风控判断，Redis 熔断或出错时放行，不影响正常用户
+		// 风控还会查询 MySQL（注册时间），只有 Redis 错误计入 Redis 熔断器
+		redisBreaker := breaker.Get(breaker.Redis)
+		done, err := redisBreaker.Allow()
+		if err != nil {
+			c.Next()
+			return
+		}
+		result, err := service.EvaluateRisk(req)
+		done(!errors.Is(err, service.ErrRiskRedis))
+		if err != nil {
+			logger.Log.Error("风控检查失败，放行请求", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		// 3. 拦截
+		if result.Blocked {
+			logger.Log.Warn("风控拦截",
+				zap.Int("uid", req.UserID),
+				zap.String("ip", req.IP),
+				zap.String("device", req.DeviceID),
+				zap.Int("score", result.Score),
+				zap.Strings("reasons", result.Reasons),
+			)
+			msg := "请求存在风险，已被拦截"
+			if result.Blacklisted {
+				msg = "账号存在异常，暂时无法参与秒杀"
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": msg})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"seckill/pkg/config"
+	"seckill/pkg/limiter"
+	"seckill/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// shedders 按路由名缓存的自适应限流器（*shedder）
+var shedders sync.Map
+
+// shedder 限流器及创建它时使用的配置，配置热更新后重建
+type shedder struct {
+	cfg config.LoadSheddingConfig
+	l   *limiter.Adaptive
+}
+
+// LoadShedding 自适应过载保护中间件
+// route: 路由名，每个路由独立统计在途请求数和延迟
+// 与固定速率的 RateLimit 互补：Redis/MySQL 变慢时自动收缩并发上限，提前拒绝多余请求
+func LoadShedding(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get().LoadShedding
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		l := getShedder(route, cfg)
+		done, ok := l.Acquire()
+		if !ok {
+			logger.Log.Warn("过载保护拒绝请求",
+				zap.String("route", route),
+				zap.Int("limit", l.Limit()),
+				zap.Int("inflight", l.Inflight()),
+			)
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "排队人数过多，请稍后再试"})
+			return
+		}
+		defer done()
+
+		c.Next()
+	}
+}
+
+// getShedder 获取路由对应的限流器，首次使用或配置变化时按当前配置创建
+// 重建后新限流器从初始上限重新学习，旧限流器上的在途请求结束时仍归还到旧限流器
+func getShedder(route string, cfg config.LoadSheddingConfig) *limiter.Adaptive {
+	old, ok := shedders.Load(route)
+	if ok && old.(*shedder).cfg == cfg {
+		return old.(*shedder).l
+	}
+	s := &shedder{cfg: cfg, l: limiter.NewAdaptive(limiter.Options{
+		InitialLimit: cfg.InitialLimit,
+		MinLimit:     cfg.MinLimit,
+		MaxLimit:     cfg.MaxLimit,
+		Tolerance:    cfg.Tolerance,
+		Smoothing:    cfg.Smoothing,
+		Window:       cfg.Window,
+		SLO:          cfg.SLO,
+	})}
+	if !ok {
+		actual, _ := shedders.LoadOrStore(route, s)
+		return actual.(*shedder).l
+	}
+	if !shedders.CompareAndSwap(route, old, s) {
+		// 其他请求已经重建
+		cur, _ := shedders.Load(route)
+		return cur.(*shedder).l
+	}
+	logger.Log.Info("过载保护配置变更，重建限流器", zap.String("route", route))
+	return s.l
+}
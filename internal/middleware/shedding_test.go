@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"seckill/pkg/config"
+	"seckill/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func TestGetShedderRebuildsOnConfigChange(t *testing.T) {
+	cfg := config.LoadSheddingConfig{Enabled: true, InitialLimit: 50, MinLimit: 10, MaxLimit: 500, SLO: 100 * time.Millisecond}
+
+	l := getShedder(t.Name(), cfg)
+	if got := l.Limit(); got != 50 {
+		t.Fatalf("Limit() = %d, want 50", got)
+	}
+	if getShedder(t.Name(), cfg) != l {
+		t.Fatal("配置不变时应复用限流器")
+	}
+
+	cfg.InitialLimit = 80
+	rebuilt := getShedder(t.Name(), cfg)
+	if rebuilt == l {
+		t.Fatal("配置变化后应重建限流器")
+	}
+	if got := rebuilt.Limit(); got != 80 {
+		t.Fatalf("Limit() = %d, want 80", got)
+	}
+	if getShedder(t.Name(), cfg) != rebuilt {
+		t.Fatal("重建后应复用新限流器")
+	}
+}
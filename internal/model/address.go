@@ -0,0 +1,22 @@
+package model
+
+import "gorm.io/gorm"
+
+// Address 用户收货地址，每个用户最多一个默认地址
+type Address struct {
+	gorm.Model
+	UserID    uint `gorm:"not null;index"` // 用户ID
+	IsDefault bool `gorm:"not null;default:false"`
+
+	AddressSnapshot
+}
+
+// AddressSnapshot 收货信息，下单时复制到订单上，之后修改或删除地址不影响已有订单
+type AddressSnapshot struct {
+	Receiver string `gorm:"type:varchar(50);not null;default:''" json:"receiver"` // 收货人
+	Phone    string `gorm:"type:varchar(20);not null;default:''" json:"phone"`    // 联系电话
+	Province string `gorm:"type:varchar(50);not null;default:''" json:"province"` // 省
+	City     string `gorm:"type:varchar(50);not null;default:''" json:"city"`     // 市
+	District string `gorm:"type:varchar(50);not null;default:''" json:"district"` // 区县
+	Detail   string `gorm:"type:varchar(255);not null;default:''" json:"detail"`  // 详细地址
+}
@@ -1,14 +1,45 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"seckill/pkg/money"
+
+	"gorm.io/gorm"
+)
 
 type Order struct {
-	gorm.Model
-	UserID    uint `gorm:"not null;index:idx_user_product,unique"` // 联合唯一索引
-	ProductID uint `gorm:"not null;index:idx_user_product,unique"` // 联合唯一索引
+	// 展开 gorm.Model，created_at 需要参与联合索引 idx_user_created（用户订单列表按创建时间分页）
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index:idx_user_created,priority:2"`
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	UserID    uint `gorm:"not null;index:idx_user_session;index:idx_user_created,priority:1"` // 用户ID
+	ProductID uint `gorm:"not null;index"`                                                    // 商品ID
+	SessionID uint `gorm:"not null;index:idx_user_session"`                                   // 秒杀场次ID（按场次限购）
+
+	Status      OrderStatus `gorm:"default:0"`               // 订单状态，只能通过状态机流转（见 order_status.go）
+	OrderNum    string      `gorm:"type:varchar(32);unique"` // 订单号 (用雪花算法生成)
+	PayDeadline time.Time   `gorm:"index"`                   // 支付截止时间，超时未支付自动取消
+	PaidAt      *time.Time  // 支付时间
+
+	// 下单时的商品和价格快照，之后修改商品或场次不影响已有订单
+	ProductName  string      `gorm:"type:varchar(100);not null;default:''"` // 商品名称
+	ProductImage string      `gorm:"type:varchar(255);not null;default:''"` // 商品主图
+	UnitPrice    money.Money `gorm:"type:bigint;not null;default:0"`        // 商品原价（分）
+	SeckillPrice money.Money `gorm:"type:bigint;not null;default:0"`        // 秒杀价（分）
+	Quantity     int         `gorm:"not null;default:1"`                    // 购买数量
+	TotalAmount  money.Money `gorm:"type:bigint;not null;default:0"`        // 订单金额（分，秒杀价 × 数量），支付按此金额
+
+	RefundedAmount money.Money `gorm:"type:bigint;not null;default:0"` // 已退款金额（分）
 
-	Status   int    `gorm:"default:0"`               // 0:未支付, 1:已支付, 2:已取消
-	OrderNum string `gorm:"type:varchar(32);unique"` // 订单号 (用雪花算法生成)
+	// 收货信息快照和物流信息
+	AddressID  uint            `gorm:"not null;default:0"`                   // 下单时选择的地址，为 0 表示下单时没有收货地址
+	Shipping   AddressSnapshot `gorm:"embedded;embeddedPrefix:ship_"`        // 收货信息
+	Carrier    string          `gorm:"type:varchar(50);not null;default:''"` // 物流公司
+	TrackingNo string          `gorm:"type:varchar(64);not null;default:''"` // 物流单号
+	ShippedAt  *time.Time      // 发货时间
 
 	// 关联关系 (可选，为了查询方便)
 	Product Product `gorm:"foreignKey:ProductID"`
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OrderStatus 订单状态
+// 数值写入数据库，0/1/2 沿用最初的未支付/已支付/已取消，新增状态依次往后排
+type OrderStatus int
+
+const (
+	OrderUnpaid    OrderStatus = 0 // 未支付
+	OrderPaid      OrderStatus = 1 // 已支付
+	OrderCancelled OrderStatus = 2 // 已取消（超时未支付或用户取消）
+	OrderCreated   OrderStatus = 3 // 已创建（初始状态，创建后立即进入未支付）
+	OrderShipped   OrderStatus = 4 // 已发货
+	OrderCompleted OrderStatus = 5 // 已完成
+	OrderRefunding OrderStatus = 6 // 退款中
+	OrderRefunded  OrderStatus = 7 // 已退款
+)
+
+// orderStatusLabels 状态的中文名，用于错误提示
+var orderStatusLabels = map[OrderStatus]string{
+	OrderUnpaid:    "未支付",
+	OrderPaid:      "已支付",
+	OrderCancelled: "已取消",
+	OrderCreated:   "已创建",
+	OrderShipped:   "已发货",
+	OrderCompleted: "已完成",
+	OrderRefunding: "退款中",
+	OrderRefunded:  "已退款",
+}
+
+var orderStatusNames = map[OrderStatus]string{
+	OrderUnpaid:    "unpaid",
+	OrderPaid:      "paid",
+	OrderCancelled: "cancelled",
+	OrderCreated:   "created",
+	OrderShipped:   "shipped",
+	OrderCompleted: "completed",
+	OrderRefunding: "refunding",
+	OrderRefunded:  "refunded",
+}
+
+// orderTransitions 合法的状态流转
+// created → unpaid → paid → shipped → completed，未支付可以取消，已支付之后可以申请退款
+// 退款中除了变为已退款，还可以回到发起退款前的状态（部分退款完成或退款失败），需要知道原状态，见 CheckRefundExit
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderCreated:   {OrderUnpaid, OrderCancelled},
+	OrderUnpaid:    {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderRefunding},
+	OrderShipped:   {OrderCompleted, OrderRefunding},
+	OrderCompleted: {OrderRefunding},
+	OrderRefunding: {OrderRefunded},
+}
+
+// String 状态名，用于接口返回和日志
+func (s OrderStatus) String() string {
+	if name, ok := orderStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// Label 状态的中文名
+func (s OrderStatus) Label() string {
+	if label, ok := orderStatusLabels[s]; ok {
+		return label
+	}
+	return s.String()
+}
+
+// ParseOrderStatus 按状态名解析订单状态
+func ParseOrderStatus(name string) (OrderStatus, bool) {
+	for s, n := range orderStatusNames {
+		if n == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// CanTransitionTo 是否可以流转到目标状态
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrIllegalTransition 非法的状态流转，可用 errors.Is 判断
+var ErrIllegalTransition = errors.New("订单状态不允许该操作")
+
+// TransitionError 非法状态流转的详细信息
+type TransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s的订单不能变更为%s", e.From.Label(), e.To.Label())
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// CheckTransition 校验状态流转，非法时返回 *TransitionError
+func (s OrderStatus) CheckTransition(to OrderStatus) error {
+	if !s.CanTransitionTo(to) {
+		return &TransitionError{From: s, To: to}
+	}
+	return nil
+}
+
+// CheckRefundExit 校验退款结束时的状态流转，resume 为发起退款前的状态
+// 只能变为已退款，或回到 resume（必须是可以发起退款的状态），不能借退款从已支付跳到已发货、已完成
+func (s OrderStatus) CheckRefundExit(resume, to OrderStatus) error {
+	if to == OrderRefunded {
+		return s.CheckTransition(to)
+	}
+	if s != OrderRefunding || to != resume || !resume.CanTransitionTo(OrderRefunding) {
+		return &TransitionError{From: s, To: to}
+	}
+	return nil
+}
+
+// OrderStatusHistory 订单状态流转记录
+type OrderStatusHistory struct {
+	ID         uint        `gorm:"primarykey"`
+	OrderID    uint        `gorm:"not null;index"`            // 订单ID
+	FromStatus OrderStatus `gorm:"not null"`                  // 原状态
+	ToStatus   OrderStatus `gorm:"not null"`                  // 新状态
+	Actor      string      `gorm:"type:varchar(64);not null"` // 操作方，如 user:1、admin:2、system、payment:mock
+	Reason     string      `gorm:"type:varchar(255)"`         // 原因
+	CreatedAt  time.Time   `gorm:"not null"`                  // 流转时间
+}
+
+// TableName 表名 order_status_history
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+var allOrderStatuses = []OrderStatus{
+	OrderUnpaid, OrderPaid, OrderCancelled, OrderCreated,
+	OrderShipped, OrderCompleted, OrderRefunding, OrderRefunded,
+}
+
+func TestOrderTransitions(t *testing.T) {
+	allowed := map[[2]OrderStatus]bool{
+		{OrderCreated, OrderUnpaid}:      true,
+		{OrderCreated, OrderCancelled}:   true,
+		{OrderUnpaid, OrderPaid}:         true,
+		{OrderUnpaid, OrderCancelled}:    true,
+		{OrderPaid, OrderShipped}:        true,
+		{OrderPaid, OrderRefunding}:      true,
+		{OrderShipped, OrderCompleted}:   true,
+		{OrderShipped, OrderRefunding}:   true,
+		{OrderCompleted, OrderRefunding}: true,
+		{OrderRefunding, OrderRefunded}:  true,
+	}
+
+	for _, from := range allOrderStatuses {
+		for _, to := range allOrderStatuses {
+			want := allowed[[2]OrderStatus{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: CanTransitionTo = %v, want %v", from, to, got, want)
+			}
+
+			err := from.CheckTransition(to)
+			if want {
+				if err != nil {
+					t.Errorf("%s -> %s: CheckTransition = %v, want nil", from, to, err)
+				}
+				continue
+			}
+			if !errors.Is(err, ErrIllegalTransition) {
+				t.Errorf("%s -> %s: CheckTransition = %v, want ErrIllegalTransition", from, to, err)
+			}
+			var te *TransitionError
+			if !errors.As(err, &te) || te.From != from || te.To != to {
+				t.Errorf("%s -> %s: CheckTransition = %#v, want *TransitionError", from, to, err)
+			}
+		}
+	}
+}
+
+func TestCheckRefundExit(t *testing.T) {
+	tests := []struct {
+		from, resume, to OrderStatus
+		ok               bool
+	}{
+		{OrderRefunding, OrderPaid, OrderRefunded, true},
+		{OrderRefunding, OrderPaid, OrderPaid, true},
+		{OrderRefunding, OrderShipped, OrderShipped, true},
+		{OrderRefunding, OrderCompleted, OrderCompleted, true},
+		// 不能借退款跳到其他状态
+		{OrderRefunding, OrderPaid, OrderShipped, false},
+		{OrderRefunding, OrderPaid, OrderCompleted, false},
+		// 原状态必须是可以发起退款的状态
+		{OrderRefunding, OrderUnpaid, OrderUnpaid, false},
+		{OrderRefunding, OrderCancelled, OrderCancelled, false},
+		// 只有退款中的订单能结束退款
+		{OrderPaid, OrderPaid, OrderPaid, false},
+		{OrderRefunded, OrderPaid, OrderRefunded, false},
+	}
+	for _, tt := range tests {
+		err := tt.from.CheckRefundExit(tt.resume, tt.to)
+		if tt.ok && err != nil {
+			t.Errorf("%s (resume %s) -> %s: CheckRefundExit = %v, want nil", tt.from, tt.resume, tt.to, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("%s (resume %s) -> %s: CheckRefundExit = %v, want ErrIllegalTransition", tt.from, tt.resume, tt.to, err)
+		}
+	}
+}
+
+func TestParseOrderStatus(t *testing.T) {
+	for _, s := range allOrderStatuses {
+		got, ok := ParseOrderStatus(s.String())
+		if !ok || got != s {
+			t.Errorf("ParseOrderStatus(%q) = %v, %v, want %v", s.String(), got, ok, s)
+		}
+	}
+	if _, ok := ParseOrderStatus("unknown"); ok {
+		t.Error("未知状态名应解析失败")
+	}
+	if got := OrderStatus(99).String(); got != "unknown(99)" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"seckill/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// 支付单状态
+const (
+	PaymentPending       = 0 // 待支付
+	PaymentSucceeded     = 1 // 支付成功
+	PaymentFailed        = 2 // 支付失败
+	PaymentRefundPending = 3 // 待退款（订单已取消或已由其他支付单支付后才收到的支付成功回调）
+	PaymentRefunded      = 4 // 已全额退款
+)
+
+// Payment 支付单：一个订单可以有多个支付单（如更换渠道），只有一个能支付成功
+type Payment struct {
+	gorm.Model
+	PaymentNo string      `gorm:"type:varchar(32);unique"`   // 支付单号，渠道回调时原样带回
+	OrderNum  string      `gorm:"type:varchar(32);index"`    // 订单号
+	UserID    uint        `gorm:"not null;index"`            // 用户ID
+	Provider  string      `gorm:"type:varchar(20);not null"` // 支付渠道
+	Amount    money.Money `gorm:"type:bigint;not null"`      // 支付金额（分）
+	Status    int         `gorm:"not null;default:0"`        // 0:待支付, 1:支付成功, 2:支付失败, 3:待退款, 4:已退款
+	TradeNo   string      `gorm:"type:varchar(64)"`          // 渠道交易号
+	PayURL    string      `gorm:"type:varchar(255)"`         // 支付链接
+	PaidAt    *time.Time  // 渠道确认支付的时间
+}
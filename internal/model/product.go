@@ -1,19 +1,17 @@
 package model
 
 import (
-	"time"
+	"seckill/pkg/money"
 
 	"gorm.io/gorm"
 )
 
+// Product 商品基础信息，秒杀价、库存和时间在 SeckillSession 中
 type Product struct {
 	gorm.Model
-	Name         string    `gorm:"type:varchar(100);not null"`  // 商品名称
-	Price        float64   `gorm:"type:decimal(10,2);not null"` // 商品原价
-	SeckillPrice float64   `gorm:"type:decimal(10,2);not null"` // 秒杀价
-	Stock        int       `gorm:"not null"`                    // 库存数量
-	Description  string    `gorm:"type:text"`                   // 商品描述
-	ImageURL     string    `gorm:"type:varchar(255)"`           // 商品图片URL
-	StartTime    time.Time `gorm:"not null"`                    // 秒杀开始时间
-	EndTime      time.Time `gorm:"not null"`                    // 秒杀结束时间
+	Name        string      `gorm:"type:varchar(100);not null"` // 商品名称
+	Price       money.Money `gorm:"type:bigint;not null"`       // 商品原价（分）
+	Description string      `gorm:"type:text"`                  // 商品描述
+	ImageURL    string      `gorm:"type:varchar(255)"`          // 商品主图URL
+	Images      []string    `gorm:"type:json;serializer:json"`  // 商品详情图URL列表
 }
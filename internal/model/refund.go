@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"seckill/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// 退款单状态
+const (
+	RefundPending   = 0 // 退款中（已提交渠道，等待结果）
+	RefundSucceeded = 1 // 退款成功
+	RefundFailed    = 2 // 退款失败
+)
+
+// Refund 退款单：对已支付订单的全额或部分退款，也用于退还订单关闭后才到账的支付单
+type Refund struct {
+	gorm.Model
+	RefundNo         string       `gorm:"type:varchar(32);unique"`   // 退款单号，渠道回调时原样带回
+	OrderNum         string       `gorm:"type:varchar(32);index"`    // 订单号
+	PaymentNo        string       `gorm:"type:varchar(32);index"`    // 原支付单号
+	UserID           uint         `gorm:"not null;index"`            // 订单所属用户
+	Provider         string       `gorm:"type:varchar(20);not null"` // 支付渠道
+	Amount           money.Money  `gorm:"type:bigint;not null"`      // 退款金额（分）
+	Reason           string       `gorm:"type:varchar(255)"`         // 退款原因
+	Actor            string       `gorm:"type:varchar(64);not null"` // 发起方，如 user:1、admin:2
+	Status           int          `gorm:"not null;default:0"`        // 0:退款中, 1:退款成功, 2:退款失败
+	FromStatus       *OrderStatus // 发起退款前的订单状态，部分退款完成或退款失败后恢复；为空表示只退支付单、不改订单
+	ProviderRefundNo string       `gorm:"type:varchar(64)"` // 渠道退款单号
+	RefundedAt       *time.Time   // 退款完成时间
+}
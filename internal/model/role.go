@@ -0,0 +1,73 @@
+package model
+
+// 角色：写入用户表和 access token 的 role 声明
+const (
+	RoleUser     = "user"     // 普通用户
+	RoleSupport  = "support"  // 客服：查看订单和用户
+	RoleOperator = "operator" // 运营：管理商品、活动和订单
+	RoleAdmin    = "admin"    // 超级管理员：全部权限
+)
+
+// 权限：后台接口按权限校验，角色只是权限的集合
+const (
+	PermProductManage  = "product:manage"  // 商品管理
+	PermActivityManage = "activity:manage" // 秒杀活动管理
+	PermOrderRead      = "order:read"      // 查看订单
+	PermOrderManage    = "order:manage"    // 订单发货、退款等操作
+	PermUserRead       = "user:read"       // 查看用户
+	PermUserManage     = "user:manage"     // 禁用用户、分配角色
+	PermRiskManage     = "risk:manage"     // 风控黑名单
+	PermSystemRead     = "system:read"     // 查看配置和运行指标
+)
+
+// rolePermissions 角色拥有的权限，admin 拥有全部权限不在此列出
+var rolePermissions = map[string][]string{
+	RoleSupport: {
+		PermOrderRead,
+		PermUserRead,
+	},
+	RoleOperator: {
+		PermProductManage,
+		PermActivityManage,
+		PermOrderRead,
+		PermOrderManage,
+		PermUserRead,
+		PermRiskManage,
+	},
+}
+
+// ValidRole 判断角色名是否有效
+func ValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleSupport, RoleOperator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// StaffRoles 后台角色，可以访问 /api/admin
+var StaffRoles = []string{RoleSupport, RoleOperator, RoleAdmin}
+
+// RoleHasPermission 判断角色是否拥有某个权限
+func RoleHasPermission(role, perm string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions 角色的全部权限
+func RolePermissions(role string) []string {
+	if role == RoleAdmin {
+		return []string{
+			PermProductManage, PermActivityManage, PermOrderRead, PermOrderManage,
+			PermUserRead, PermUserManage, PermRiskManage, PermSystemRead,
+		}
+	}
+	return rolePermissions[role]
+}
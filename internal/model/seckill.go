@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"seckill/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// SeckillActivity 秒杀活动（如“双十一秒杀”），一个活动包含多个场次
+type SeckillActivity struct {
+	gorm.Model
+	Name        string `gorm:"type:varchar(100);not null"` // 活动名称
+	Description string `gorm:"type:text"`                  // 活动描述
+
+	Sessions []SeckillSession `gorm:"foreignKey:ActivityID"`
+}
+
+// SeckillSession 秒杀场次：某个商品在某个时间段的秒杀
+// 同一商品可以出现在多个场次（如 10:00、14:00、20:00），各自独立定价、库存和限购
+type SeckillSession struct {
+	gorm.Model
+	ActivityID   uint        `gorm:"not null;index"`       // 所属活动
+	ProductID    uint        `gorm:"not null;index"`       // 商品
+	SeckillPrice money.Money `gorm:"type:bigint;not null"` // 秒杀价（分）
+	TotalStock   int         `gorm:"not null"`             // 场次投放库存
+	Stock        int         `gorm:"not null"`             // 剩余库存（已创建订单扣减后）
+	PerUserLimit int         `gorm:"not null;default:1"`   // 每人限购数量
+	StartTime    time.Time   `gorm:"not null;index"`       // 开始时间
+	EndTime      time.Time   `gorm:"not null"`             // 结束时间
+
+	// 场次结束后由清理任务归档，归档后 Redis 中的 key 会被删除
+	SoldCount  int        `gorm:"not null;default:0"` // 最终售出数量（投放库存减剩余库存）
+	BuyerCount int        `gorm:"not null;default:0"` // 购买人数
+	ArchivedAt *time.Time `gorm:"index"`              // 归档时间，为空表示未归档
+
+	Product Product `gorm:"foreignKey:ProductID"`
+}
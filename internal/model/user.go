@@ -10,5 +10,6 @@ type User struct {
 	Email    string `gorm:"type:varchar(100);comment:邮箱"`
 	Avatar   string `gorm:"type:varchar(255);comment:头像URL"`
 	Status   int    `gorm:"default:1;comment:状态 1:正常 2:禁用"`
-	IsAdmin  bool   `gorm:"default:false;comment:是否管理员"`
+	IsAdmin  bool   `gorm:"default:false;comment:是否管理员（已废弃，使用 Role）"`
+	Role     string `gorm:"type:varchar(20);default:user;not null;comment:角色 user/support/operator/admin"`
 }
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"expvar"
+
 	"github.com/gin-gonic/gin"
 
 	// 引入 Swagger 相关包
@@ -13,6 +15,7 @@ import (
 	// 引入业务包
 	"seckill/internal/controller"
 	"seckill/internal/middleware"
+	"seckill/internal/model"
 )
 
 // NewRouter 负责初始化 Gin 引擎，加载中间件和注册路由
@@ -32,6 +35,14 @@ func NewRouter() *gin.Engine {
 	// 4. 实例化 Controller
 	userCtrl := &controller.UserController{}
 	seckillCtrl := &controller.SeckillController{}
+	adminCtrl := &controller.AdminController{}
+	productCtrl := &controller.ProductController{}
+	orderCtrl := &controller.OrderController{}
+	paymentCtrl := &controller.PaymentController{}
+	addressCtrl := &controller.AddressController{}
+
+	// JWT 公钥集，供网关和其他服务验签
+	r.GET("/.well-known/jwks.json", userCtrl.JWKS)
 
 	// 5. 注册业务路由
 	api := r.Group("/api")
@@ -39,12 +50,96 @@ func NewRouter() *gin.Engine {
 		// 公开接口
 		api.POST("/register", userCtrl.Register)
 		api.POST("/login", userCtrl.Login)
+		api.POST("/token/refresh", userCtrl.Refresh)
+		api.GET("/products", productCtrl.List)
+		api.GET("/products/:id", productCtrl.Get)
+
+		// 支付渠道回调（通过签名校验，不走 JWT）
+		api.POST("/payments/callback/:provider", paymentCtrl.Callback)
+		api.POST("/payments/refund-callback/:provider", paymentCtrl.RefundCallback)
+		api.POST("/payments/mock/:payment_no/pay", paymentCtrl.MockPay) // 模拟支付，未启用时返回 404
 
 		// 🔒 需要鉴权的接口组
 		authGroup := api.Group("/")
 		authGroup.Use(middleware.JWTAuth()) // 挂载中间件
 		{
-			authGroup.POST("/seckill/buy", seckillCtrl.Buy)
+			authGroup.POST("/logout", userCtrl.Logout)
+			authGroup.POST("/seckill/buy",
+				middleware.RateLimit("seckill_buy"),    // 固定速率限流
+				middleware.LoadShedding("seckill_buy"), // 自适应过载保护
+				middleware.RiskControl(),               // 风控与黑名单
+				seckillCtrl.Buy,
+			)
+			authGroup.GET("/seckill/result", seckillCtrl.Result)
+			authGroup.GET("/orders", orderCtrl.List)
+			authGroup.GET("/orders/:order_num", orderCtrl.Get)
+			authGroup.POST("/orders/:order_num/pay", orderCtrl.Pay)
+			authGroup.POST("/orders/:order_num/cancel", orderCtrl.Cancel)
+			authGroup.POST("/orders/:order_num/refund", orderCtrl.Refund)
+			authGroup.PUT("/orders/:order_num/address", orderCtrl.SetAddress)
+			authGroup.GET("/addresses", addressCtrl.List)
+			authGroup.POST("/addresses", addressCtrl.Create)
+			authGroup.PUT("/addresses/:id", addressCtrl.Update)
+			authGroup.DELETE("/addresses/:id", addressCtrl.Delete)
+			authGroup.PUT("/addresses/:id/default", addressCtrl.SetDefault)
+		}
+
+		// 🔒 SSE 长连接：EventSource 不能设置请求头，允许通过查询参数传递 Token
+		api.GET("/seckill/events", middleware.JWTAuthAllowQuery(), seckillCtrl.Events)
+
+		// 🔒 管理接口组：只有后台角色可以进入，具体接口再按权限校验
+		adminGroup := api.Group("/admin")
+		adminGroup.Use(middleware.JWTAuth(), middleware.RequireRole(model.StaffRoles...))
+		{
+			adminGroup.GET("/permissions", adminCtrl.Permissions)
+
+			// 系统运维
+			system := adminGroup.Group("/", middleware.RequirePermission(model.PermSystemRead))
+			system.GET("/config", adminCtrl.Config)
+			system.GET("/metrics", gin.WrapH(expvar.Handler())) // 运行指标（含熔断器状态）
+
+			// 风控黑名单
+			risk := adminGroup.Group("/blacklist", middleware.RequirePermission(model.PermRiskManage))
+			risk.GET("", adminCtrl.ListBlacklist)
+			risk.POST("", adminCtrl.AddBlacklist)
+			risk.DELETE("/:type/:value", adminCtrl.RemoveBlacklist)
+
+			// 商品管理
+			products := adminGroup.Group("/products", middleware.RequirePermission(model.PermProductManage))
+			products.GET("", adminCtrl.ListProducts)
+			products.POST("", adminCtrl.CreateProduct)
+			products.GET("/:id", adminCtrl.GetProduct)
+			products.PUT("/:id", adminCtrl.UpdateProduct)
+			products.DELETE("/:id", adminCtrl.DeleteProduct)
+
+			// 秒杀活动与场次
+			activities := adminGroup.Group("/activities", middleware.RequirePermission(model.PermActivityManage))
+			activities.GET("", adminCtrl.ListActivities)
+			activities.POST("", adminCtrl.CreateActivity)
+			activities.GET("/:id", adminCtrl.GetActivity)
+			activities.PUT("/:id", adminCtrl.UpdateActivity)
+			activities.DELETE("/:id", adminCtrl.DeleteActivity)
+			activities.POST("/:id/sessions", adminCtrl.CreateSession)
+			sessions := adminGroup.Group("/sessions", middleware.RequirePermission(model.PermActivityManage))
+			sessions.PUT("/:id", adminCtrl.UpdateSession)
+			sessions.DELETE("/:id", adminCtrl.DeleteSession)
+			sessions.POST("/:id/stock", adminCtrl.AdjustSessionStock) // 场次进行中只能通过这里调整库存
+
+			// 订单查询（客服）和售后
+			orderRead := adminGroup.Group("/orders", middleware.RequirePermission(model.PermOrderRead))
+			orderRead.GET("", adminCtrl.ListOrders)
+			orderRead.GET("/:order_num", adminCtrl.GetOrder)
+			orders := adminGroup.Group("/", middleware.RequirePermission(model.PermOrderManage))
+			orders.PUT("/orders/:order_num/address", adminCtrl.SetOrderAddress) // 下单时没有地址的订单补录后才能发货
+			orders.POST("/orders/:order_num/ship", adminCtrl.ShipOrder)
+			orders.POST("/orders/:order_num/refund", adminCtrl.RefundOrder)
+			orders.POST("/payments/:payment_no/refund", adminCtrl.RefundPayment) // 订单关闭后才到账的支付单
+
+			// 用户管理
+			adminGroup.GET("/users", middleware.RequirePermission(model.PermUserRead), adminCtrl.ListUsers)
+			users := adminGroup.Group("/users", middleware.RequirePermission(model.PermUserManage))
+			users.PUT("/:id/status", adminCtrl.SetUserStatus)
+			users.PUT("/:id/role", middleware.RequireRole(model.RoleAdmin), adminCtrl.SetUserRole) // 只有超级管理员能分配角色
 		}
 	}
 
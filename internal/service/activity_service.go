@@ -0,0 +1,560 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+	"seckill/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 后台秒杀活动与场次管理
+// MySQL 是场次数据的来源，库存、时间和限购同时写入 Redis 供秒杀脚本使用
+// Redis 写入放在数据库事务内，失败时事务回滚，保证两边一致
+// 场次进行中不能直接修改库存、时间和限购，只能通过 AdjustSessionStock 增减库存
+
+var (
+	ErrActivityNotFound = errors.New("活动不存在")
+	ErrSessionNotFound  = errors.New("场次不存在")
+	ErrSessionOnSale    = errors.New("场次进行中，不能修改库存、时间和限购，请使用库存调整")
+	ErrInvalidActivity  = errors.New("活动参数错误")
+	ErrInvalidSession   = errors.New("场次参数错误")
+	ErrSessionArchived  = errors.New("场次已结束归档，不能修改库存、时间和限购")
+)
+
+// ActivityInput 创建/修改活动的参数
+type ActivityInput struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// SessionInput 创建/修改场次的参数，修改时为空的字段保持不变
+type SessionInput struct {
+	ProductID    *uint        `json:"product_id"`
+	SeckillPrice *money.Money `json:"seckill_price"`
+	Stock        *int         `json:"stock"`
+	PerUserLimit *int         `json:"per_user_limit"`
+	StartTime    *time.Time   `json:"start_time"`
+	EndTime      *time.Time   `json:"end_time"`
+}
+
+// touchesSale 是否修改了秒杀脚本依赖的字段
+func (in *SessionInput) touchesSale() bool {
+	return in.ProductID != nil || in.Stock != nil || in.PerUserLimit != nil ||
+		in.StartTime != nil || in.EndTime != nil
+}
+
+// apply 把参数写入场次，修改库存时投放库存同步变化
+func (in *SessionInput) apply(s *model.SeckillSession) {
+	if in.ProductID != nil {
+		s.ProductID = *in.ProductID
+	}
+	if in.SeckillPrice != nil {
+		s.SeckillPrice = *in.SeckillPrice
+	}
+	if in.Stock != nil {
+		s.TotalStock += *in.Stock - s.Stock
+		s.Stock = *in.Stock
+	}
+	if in.PerUserLimit != nil {
+		s.PerUserLimit = *in.PerUserLimit
+	}
+	if in.StartTime != nil {
+		s.StartTime = *in.StartTime
+	}
+	if in.EndTime != nil {
+		s.EndTime = *in.EndTime
+	}
+}
+
+// ActivityView 活动信息
+type ActivityView struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Sessions    []*SessionView `json:"sessions,omitempty"`
+}
+
+// SessionView 场次信息
+type SessionView struct {
+	ID           uint        `json:"id"`
+	ActivityID   uint        `json:"activity_id"`
+	ProductID    uint        `json:"product_id"`
+	SeckillPrice money.Money `json:"seckill_price"`
+	TotalStock   int         `json:"total_stock"`
+	Stock        int         `json:"stock"` // MySQL 剩余库存（已创建订单扣减后）
+	PerUserLimit int         `json:"per_user_limit"`
+	StartTime    time.Time   `json:"start_time"`
+	EndTime      time.Time   `json:"end_time"`
+	RedisStock   *int64      `json:"redis_stock,omitempty"` // Redis 剩余可抢库存
+
+	// 场次结束归档后的统计，未归档时不返回
+	SoldCount  *int       `json:"sold_count,omitempty"`
+	BuyerCount *int       `json:"buyer_count,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+func newActivityView(a *model.SeckillActivity) *ActivityView {
+	view := &ActivityView{
+		ID:          a.ID,
+		Name:        a.Name,
+		Description: a.Description,
+		CreatedAt:   a.CreatedAt,
+	}
+	for i := range a.Sessions {
+		view.Sessions = append(view.Sessions, newSessionView(&a.Sessions[i]))
+	}
+	return view
+}
+
+func newSessionView(s *model.SeckillSession) *SessionView {
+	view := &SessionView{
+		ID:           s.ID,
+		ActivityID:   s.ActivityID,
+		ProductID:    s.ProductID,
+		SeckillPrice: s.SeckillPrice,
+		TotalStock:   s.TotalStock,
+		Stock:        s.Stock,
+		PerUserLimit: s.PerUserLimit,
+		StartTime:    s.StartTime,
+		EndTime:      s.EndTime,
+	}
+	if s.ArchivedAt != nil {
+		view.SoldCount = &s.SoldCount
+		view.BuyerCount = &s.BuyerCount
+		view.ArchivedAt = s.ArchivedAt
+	}
+	return view
+}
+
+// sessionOnSale 场次当前是否在售卖时间内
+func sessionOnSale(s *model.SeckillSession, now time.Time) bool {
+	return !now.Before(s.StartTime) && now.Before(s.EndTime)
+}
+
+// validateSession 校验场次字段，秒杀价不能高于商品原价
+func validateSession(tx *gorm.DB, s *model.SeckillSession) error {
+	switch {
+	case s.SeckillPrice <= 0:
+		return fmt.Errorf("%w: 秒杀价必须大于 0", ErrInvalidSession)
+	case s.Stock < 0:
+		return fmt.Errorf("%w: 库存不能为负数", ErrInvalidSession)
+	case s.PerUserLimit < 1:
+		return fmt.Errorf("%w: 每人限购数量至少为 1", ErrInvalidSession)
+	case s.StartTime.IsZero() || s.EndTime.IsZero():
+		return fmt.Errorf("%w: 必须设置开始和结束时间", ErrInvalidSession)
+	case !s.EndTime.After(s.StartTime):
+		return fmt.Errorf("%w: 结束时间必须晚于开始时间", ErrInvalidSession)
+	}
+	var product model.Product
+	if err := tx.Select("id", "price").First(&product, s.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	if s.SeckillPrice > product.Price {
+		return fmt.Errorf("%w: 秒杀价不能高于商品原价", ErrInvalidSession)
+	}
+	return nil
+}
+
+// syncSessionToRedis 把场次库存、时间和限购写入 Redis
+func syncSessionToRedis(ctx context.Context, s *model.SeckillSession) error {
+	pipe := redis.Client.TxPipeline()
+	writeSessionToRedis(ctx, pipe, s, true)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// resyncSession MySQL 提交后覆盖写入 Redis
+// 写入失败时删除场次的库存和信息 key，由预热任务按 MySQL 重建，避免 Redis 保留修改前的库存和时间
+func resyncSession(s *model.SeckillSession) {
+	ctx := context.Background()
+	err := syncSessionToRedis(ctx, s)
+	if err == nil {
+		return
+	}
+	logger.Log.Error("同步场次到 Redis 失败，删除旧数据等待预热任务重建", zap.Uint("session_id", s.ID), zap.Error(err))
+	if err := redis.Client.Del(ctx, redis.SessionStockKey(s.ID), redis.SessionInfoKey(s.ID)).Err(); err != nil {
+		logger.Log.Error("删除场次 key 失败，Redis 与 MySQL 不一致", zap.Uint("session_id", s.ID), zap.Error(err))
+	}
+}
+
+// writeSessionToRedis 把场次写入 pipe，由调用方统一执行
+// overwriteStock 为 false 时库存只在不存在时写入，不覆盖抢购中已扣减的库存
+// 场次的 key 在结束后 key_ttl 过期，已购记录由秒杀脚本写入时按 info 中的 expire 设置过期
+func writeSessionToRedis(ctx context.Context, pipe goredis.Pipeliner, s *model.SeckillSession, overwriteStock bool) {
+	expireAt := s.EndTime.Add(config.Get().Cleanup.KeyTTL)
+	if overwriteStock {
+		pipe.Set(ctx, redis.SessionStockKey(s.ID), s.Stock, 0)
+	} else {
+		pipe.SetNX(ctx, redis.SessionStockKey(s.ID), s.Stock, 0)
+	}
+	pipe.HSet(ctx, redis.SessionInfoKey(s.ID),
+		"start", s.StartTime.Unix(),
+		"end", s.EndTime.Unix(),
+		"limit", s.PerUserLimit,
+		"expire", expireAt.Unix(),
+	)
+	for _, key := range redis.SessionKeys(s.ID) {
+		pipe.ExpireAt(ctx, key, expireAt)
+	}
+	redis.SessionBloom.Add(ctx, pipe, strconv.FormatUint(uint64(s.ID), 10))
+}
+
+// lockSession 加行锁读取场次
+func lockSession(tx *gorm.DB, id uint) (*model.SeckillSession, error) {
+	var s model.SeckillSession
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	return &s, err
+}
+
+// CreateActivity 创建活动
+func CreateActivity(in ActivityInput) (*ActivityView, error) {
+	a := model.SeckillActivity{}
+	if in.Name != nil {
+		a.Name = *in.Name
+	}
+	if in.Description != nil {
+		a.Description = *in.Description
+	}
+	if a.Name == "" {
+		return nil, fmt.Errorf("%w: 活动名称不能为空", ErrInvalidActivity)
+	}
+	if err := database.DB.Create(&a).Error; err != nil {
+		logger.Log.Error("创建活动失败", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	logger.Log.Info("创建活动", zap.Uint("activity_id", a.ID), zap.String("name", a.Name))
+	return newActivityView(&a), nil
+}
+
+// UpdateActivity 修改活动名称和描述
+func UpdateActivity(id uint, in ActivityInput) (*ActivityView, error) {
+	var a model.SeckillActivity
+	if err := database.DB.First(&a, id).Error; err != nil {
+		return nil, activityError("修改活动失败", err)
+	}
+	if in.Name != nil {
+		if *in.Name == "" {
+			return nil, fmt.Errorf("%w: 活动名称不能为空", ErrInvalidActivity)
+		}
+		a.Name = *in.Name
+	}
+	if in.Description != nil {
+		a.Description = *in.Description
+	}
+	if err := database.DB.Save(&a).Error; err != nil {
+		return nil, activityError("修改活动失败", err)
+	}
+	return newActivityView(&a), nil
+}
+
+// DeleteActivity 删除活动及其场次，有进行中的场次时不能删除
+func DeleteActivity(id uint) error {
+	var a model.SeckillActivity
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Sessions").First(&a, id).Error; err != nil {
+			return err
+		}
+		for i := range a.Sessions {
+			if sessionOnSale(&a.Sessions[i], time.Now()) {
+				return ErrSessionOnSale
+			}
+		}
+		if err := tx.Where("activity_id = ?", id).Delete(&model.SeckillSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&a).Error; err != nil {
+			return err
+		}
+		// 每个场次的 key 在同一个槽，按场次分别删除
+		ctx := context.Background()
+		pipe := redis.Client.Pipeline()
+		for _, s := range a.Sessions {
+			pipe.Del(ctx, redis.SessionKeys(s.ID)...)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return activityError("删除活动失败", err)
+	}
+	pids := make([]uint, 0, len(a.Sessions))
+	for _, s := range a.Sessions {
+		pids = append(pids, s.ProductID)
+	}
+	InvalidateProductCache(pids...)
+	logger.Log.Info("删除活动", zap.Uint("activity_id", id))
+	return nil
+}
+
+// GetActivity 活动详情，包含全部场次及 Redis 剩余库存
+func GetActivity(id uint) (*ActivityView, error) {
+	var a model.SeckillActivity
+	err := database.DB.Preload("Sessions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_time")
+	}).First(&a, id).Error
+	if err != nil {
+		return nil, activityError("查询活动失败", err)
+	}
+	view := newActivityView(&a)
+	fillRedisStock(view.Sessions)
+	return view, nil
+}
+
+// ListActivities 分页查询活动
+func ListActivities(page, size int) ([]*ActivityView, int64, error) {
+	var total int64
+	var activities []model.SeckillActivity
+	db := database.DB.Model(&model.SeckillActivity{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, activityError("查询活动失败", err)
+	}
+	if err := db.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&activities).Error; err != nil {
+		return nil, 0, activityError("查询活动失败", err)
+	}
+	views := make([]*ActivityView, 0, len(activities))
+	for i := range activities {
+		views = append(views, newActivityView(&activities[i]))
+	}
+	return views, total, nil
+}
+
+// CreateSession 在活动下创建场次
+func CreateSession(activityID uint, in SessionInput) (*SessionView, error) {
+	s := model.SeckillSession{ActivityID: activityID, PerUserLimit: 1}
+	in.apply(&s)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&model.SeckillActivity{}, activityID).Error; err != nil {
+			return err
+		}
+		if err := validateSession(tx, &s); err != nil {
+			return err
+		}
+		return tx.Create(&s).Error
+	})
+	if err != nil {
+		return nil, activityError("创建场次失败", err)
+	}
+	// 提交后再写 Redis，失败时由预热任务按 MySQL 重新写入
+	if err := syncSessionToRedis(context.Background(), &s); err != nil {
+		logger.Log.Error("同步场次到 Redis 失败，等待预热任务写入", zap.Uint("session_id", s.ID), zap.Error(err))
+	}
+	InvalidateProductCache(s.ProductID)
+	logger.Log.Info("创建场次",
+		zap.Uint("activity_id", activityID),
+		zap.Uint("session_id", s.ID),
+		zap.Uint("pid", s.ProductID),
+		zap.Int("stock", s.Stock),
+		zap.Time("start", s.StartTime),
+		zap.Time("end", s.EndTime),
+	)
+	return newSessionView(&s), nil
+}
+
+// UpdateSession 修改场次，进行中不能修改库存、时间和限购
+func UpdateSession(id uint, in SessionInput) (*SessionView, error) {
+	var s *model.SeckillSession
+	var oldProductID uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if s, err = lockSession(tx, id); err != nil {
+			return err
+		}
+		oldProductID = s.ProductID
+		if in.touchesSale() && sessionOnSale(s, time.Now()) {
+			return ErrSessionOnSale
+		}
+		if in.touchesSale() && s.ArchivedAt != nil {
+			return ErrSessionArchived
+		}
+		in.apply(s)
+		if err := validateSession(tx, s); err != nil {
+			return err
+		}
+		return tx.Save(s).Error
+	})
+	if err != nil {
+		return nil, activityError("修改场次失败", err)
+	}
+	if in.touchesSale() {
+		resyncSession(s)
+	}
+	InvalidateProductCache(oldProductID, s.ProductID)
+	logger.Log.Info("修改场次", zap.Uint("session_id", id), zap.Bool("sale_changed", in.touchesSale()))
+	return newSessionView(s), nil
+}
+
+// AdjustSessionStock 增减场次库存（进行中也可以使用）
+// MySQL 提交后再调整 Redis，扣减时两边都必须有足够库存；Redis 调整失败时回滚 MySQL 的调整
+func AdjustSessionStock(id uint, delta int) (*SessionView, error) {
+	if delta == 0 {
+		return nil, fmt.Errorf("%w: 调整数量不能为 0", ErrInvalidSession)
+	}
+	ctx := context.Background()
+	var s *model.SeckillSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if s, err = lockSession(tx, id); err != nil {
+			return err
+		}
+		if s.ArchivedAt != nil {
+			return ErrSessionArchived
+		}
+		if s.Stock+delta < 0 {
+			return fmt.Errorf("%w: 库存不足，当前库存 %d", ErrInvalidSession, s.Stock)
+		}
+		// 场次还没有写入 Redis：先按调整前的库存重建，与预热写入的内容相同，事务回滚也不影响
+		// 持有行锁期间 MySQL 库存不会变化，同时执行的预热写入的也是调整前的库存，SetNX 谁先写入结果都一样
+		if err := ensureSessionInRedis(ctx, s); err != nil {
+			return err
+		}
+		err = tx.Model(s).Updates(map[string]interface{}{
+			"stock":       gorm.Expr("stock + ?", delta),
+			"total_stock": gorm.Expr("total_stock + ?", delta),
+		}).Error
+		if err != nil {
+			return err
+		}
+		s.Stock += delta
+		s.TotalStock += delta
+		return nil
+	})
+	if err != nil {
+		return nil, activityError("调整库存失败", err)
+	}
+
+	// Redis 中部分库存可能已被抢走但订单还未落库，扣减时以 Redis 剩余量为准
+	redisStock, err := redis.AdjustStockScript.Run(ctx, redis.Client,
+		[]string{redis.SessionStockKey(id)}, delta).Int64()
+	if err != nil || redisStock < 0 {
+		revertStockAdjust(id, delta)
+		switch {
+		case err != nil:
+			return nil, activityError("调整 Redis 库存失败", err)
+		case redisStock == -2:
+			// 重建后 key 立即过期：场次早已结束
+			return nil, fmt.Errorf("%w: 场次已结束，不能调整库存", ErrInvalidSession)
+		default:
+			return nil, fmt.Errorf("%w: 可抢库存不足，无法扣减", ErrInvalidSession)
+		}
+	}
+	InvalidateProductCache(s.ProductID)
+	logger.Log.Info("调整场次库存",
+		zap.Uint("session_id", id),
+		zap.Int("delta", delta),
+		zap.Int("stock", s.Stock),
+		zap.Int64("redis_stock", redisStock),
+	)
+	view := newSessionView(s)
+	view.RedisStock = &redisStock
+	return view, nil
+}
+
+// ensureSessionInRedis 场次库存 key 不存在时按当前库存写入
+func ensureSessionInRedis(ctx context.Context, s *model.SeckillSession) error {
+	n, err := redis.Client.Exists(ctx, redis.SessionStockKey(s.ID)).Result()
+	if err != nil || n > 0 {
+		return err
+	}
+	pipe := redis.Client.Pipeline()
+	writeSessionToRedis(ctx, pipe, s, false)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// revertStockAdjust Redis 调整失败时撤销已提交的 MySQL 调整
+// 撤销增加的库存时，期间已被下单扣减的部分不能撤销，此时记录日志由人工处理
+func revertStockAdjust(id uint, delta int) {
+	result := database.DB.Model(&model.SeckillSession{}).
+		Where("id = ? AND stock >= ?", id, delta).
+		Updates(map[string]interface{}{
+			"stock":       gorm.Expr("stock - ?", delta),
+			"total_stock": gorm.Expr("total_stock - ?", delta),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		logger.Log.Error("撤销库存调整失败，MySQL 与 Redis 库存不一致",
+			zap.Uint("session_id", id), zap.Int("delta", delta), zap.Error(result.Error))
+	}
+}
+
+// DeleteSession 删除场次并清理 Redis，进行中不能删除
+func DeleteSession(id uint) error {
+	var s *model.SeckillSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if s, err = lockSession(tx, id); err != nil {
+			return err
+		}
+		if sessionOnSale(s, time.Now()) {
+			return ErrSessionOnSale
+		}
+		return tx.Delete(s).Error
+	})
+	if err != nil {
+		return activityError("删除场次失败", err)
+	}
+	// 删除失败不影响结果，key 到期后会自动过期
+	if err := redis.Client.Del(context.Background(), redis.SessionKeys(id)...).Err(); err != nil {
+		logger.Log.Warn("删除场次 key 失败", zap.Uint("session_id", id), zap.Error(err))
+	}
+	InvalidateProductCache(s.ProductID)
+	logger.Log.Info("删除场次", zap.Uint("session_id", id))
+	return nil
+}
+
+// fillRedisStock 批量读取场次在 Redis 中的剩余库存
+func fillRedisStock(sessions []*SessionView) {
+	if len(sessions) == 0 {
+		return
+	}
+	ids := make([]uint, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.ID
+	}
+	stocks, err := redis.SessionStocks(context.Background(), ids)
+	if err != nil {
+		logger.Log.Warn("读取场次 Redis 库存失败", zap.Error(err))
+		return
+	}
+	for _, s := range sessions {
+		if stock, ok := stocks[s.ID]; ok {
+			s.RedisStock = &stock
+		}
+	}
+}
+
+// activityError 业务错误原样返回，其余记录日志后返回通用错误
+func activityError(msg string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrActivityNotFound
+	}
+	for _, target := range []error{
+		ErrActivityNotFound, ErrSessionNotFound, ErrSessionOnSale, ErrSessionArchived,
+		ErrInvalidActivity, ErrInvalidSession, ErrProductNotFound,
+	} {
+		if errors.Is(err, target) {
+			return err
+		}
+	}
+	logger.Log.Error(msg, zap.Error(err))
+	return errors.New("系统内部错误，请稍后再试")
+}
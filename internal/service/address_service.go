@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 收货地址：每个用户最多 maxAddresses 个地址，其中一个为默认地址
+// 第一个地址自动设为默认；删除默认地址时把最近添加的地址设为默认
+// 抢购时可以指定地址，不指定时下单使用默认地址，订单保存地址快照
+
+// maxAddresses 每个用户最多保存的地址数
+const maxAddresses = 20
+
+var (
+	ErrAddressNotFound = errors.New("收货地址不存在")
+	ErrTooManyAddress  = errors.New("收货地址数量已达上限")
+)
+
+// AddressInput 创建/修改地址的参数
+type AddressInput struct {
+	model.AddressSnapshot
+	IsDefault bool `json:"is_default"`
+}
+
+// AddressView 地址信息
+type AddressView struct {
+	ID uint `json:"id"`
+	model.AddressSnapshot
+	IsDefault bool      `json:"is_default"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newAddressView(a *model.Address) *AddressView {
+	return &AddressView{
+		ID:              a.ID,
+		AddressSnapshot: a.AddressSnapshot,
+		IsDefault:       a.IsDefault,
+		UpdatedAt:       a.UpdatedAt,
+	}
+}
+
+// ListAddresses 用户的地址列表，默认地址排在最前
+func ListAddresses(uid uint) ([]*AddressView, error) {
+	var addresses []model.Address
+	err := database.DB.Where("user_id = ?", uid).
+		Order("is_default DESC, id DESC").
+		Find(&addresses).Error
+	if err != nil {
+		logger.Log.Error("查询收货地址失败", zap.Uint("uid", uid), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	views := make([]*AddressView, 0, len(addresses))
+	for i := range addresses {
+		views = append(views, newAddressView(&addresses[i]))
+	}
+	return views, nil
+}
+
+// CreateAddress 添加地址
+func CreateAddress(uid uint, in *AddressInput) (*AddressView, error) {
+	a := model.Address{UserID: uid, AddressSnapshot: in.AddressSnapshot}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁住用户行，避免并发添加超过上限或出现多个默认地址
+		if err := lockUserRow(tx, uid); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.Address{}).Where("user_id = ?", uid).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxAddresses {
+			return ErrTooManyAddress
+		}
+		a.IsDefault = in.IsDefault || count == 0
+		if a.IsDefault {
+			if err := clearDefaultAddress(tx, uid); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&a).Error
+	})
+	if err != nil {
+		return nil, addressError(uid, err)
+	}
+	return newAddressView(&a), nil
+}
+
+// UpdateAddress 修改地址，is_default 为 true 时同时设为默认（为 false 不会取消默认）
+func UpdateAddress(uid, id uint, in *AddressInput) (*AddressView, error) {
+	var a model.Address
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserRow(tx, uid); err != nil {
+			return err
+		}
+		if err := findAddress(tx, uid, id, &a); err != nil {
+			return err
+		}
+		a.AddressSnapshot = in.AddressSnapshot
+		if in.IsDefault && !a.IsDefault {
+			if err := clearDefaultAddress(tx, uid); err != nil {
+				return err
+			}
+			a.IsDefault = true
+		}
+		return tx.Save(&a).Error
+	})
+	if err != nil {
+		return nil, addressError(uid, err)
+	}
+	return newAddressView(&a), nil
+}
+
+// SetDefaultAddress 设为默认地址
+func SetDefaultAddress(uid, id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserRow(tx, uid); err != nil {
+			return err
+		}
+		var a model.Address
+		if err := findAddress(tx, uid, id, &a); err != nil {
+			return err
+		}
+		if err := clearDefaultAddress(tx, uid); err != nil {
+			return err
+		}
+		return tx.Model(&a).Update("is_default", true).Error
+	})
+	return addressError(uid, err)
+}
+
+// DeleteAddress 删除地址，已下单的订单保存了地址快照，不受影响
+func DeleteAddress(uid, id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserRow(tx, uid); err != nil {
+			return err
+		}
+		var a model.Address
+		if err := findAddress(tx, uid, id, &a); err != nil {
+			return err
+		}
+		if err := tx.Delete(&a).Error; err != nil {
+			return err
+		}
+		if !a.IsDefault {
+			return nil
+		}
+		// 删除的是默认地址，最近添加的地址成为默认
+		var next model.Address
+		err := tx.Where("user_id = ?", uid).Order("id DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+	return addressError(uid, err)
+}
+
+// orderAddress 下单时的收货地址：优先使用指定的地址（抢购后被删除的也可以），否则使用默认地址
+// 指定的地址不属于该用户时视为未指定；都没有时返回 nil，订单没有收货信息
+func orderAddress(tx *gorm.DB, uid, id uint) (*model.Address, error) {
+	var a model.Address
+	if id != 0 {
+		err := tx.Unscoped().Where("id = ? AND user_id = ?", id, uid).First(&a).Error
+		if err == nil {
+			return &a, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	err := tx.Where("user_id = ? AND is_default = ?", uid, true).First(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// lockUserRow 锁住用户行，串行化同一用户的地址修改
+func lockUserRow(tx *gorm.DB, uid uint) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		First(&model.User{}, uid).Error
+}
+
+// findAddress 查询用户自己的地址
+func findAddress(tx *gorm.DB, uid, id uint, a *model.Address) error {
+	err := tx.Where("id = ? AND user_id = ?", id, uid).First(a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAddressNotFound
+	}
+	return err
+}
+
+// clearDefaultAddress 取消用户当前的默认地址
+func clearDefaultAddress(tx *gorm.DB, uid uint) error {
+	return tx.Model(&model.Address{}).
+		Where("user_id = ? AND is_default = ?", uid, true).
+		Update("is_default", false).Error
+}
+
+// addressError 业务错误直接返回，其他错误记录日志后返回通用错误
+func addressError(uid uint, err error) error {
+	if err == nil || errors.Is(err, ErrAddressNotFound) || errors.Is(err, ErrTooManyAddress) {
+		return err
+	}
+	logger.Log.Error("操作收货地址失败", zap.Uint("uid", uid), zap.Error(err))
+	return errors.New("系统内部错误，请稍后再试")
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 后台用户管理：查询用户、禁用/启用、分配角色
+// 禁用和角色变更都会踢下线，使 token 中的旧状态立即失效
+
+var (
+	ErrUserNotFound = errors.New("用户不存在")
+	ErrInvalidRole  = errors.New("角色只能是 user/support/operator/admin")
+	ErrModifySelf   = errors.New("不能修改自己的状态或角色")
+)
+
+// AdminUserView 后台用户列表项（不含密码）
+type AdminUserView struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Phone     string    `json:"phone"`
+	Email     string    `json:"email"`
+	Status    int       `json:"status"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserQuery 后台用户查询条件
+type UserQuery struct {
+	Keyword string // 用户名或手机号前缀
+	Status  int    // 0 表示不过滤
+	Role    string // 空表示不过滤
+	Page    int
+	Size    int
+}
+
+// ListUsers 分页查询用户
+func ListUsers(q UserQuery) ([]AdminUserView, int64, error) {
+	db := database.DB.Model(&model.User{})
+	if q.Keyword != "" {
+		db = db.Where("username LIKE ? OR phone LIKE ?", q.Keyword+"%", q.Keyword+"%")
+	}
+	if q.Status != 0 {
+		db = db.Where("status = ?", q.Status)
+	}
+	if q.Role != "" {
+		db = db.Where("role = ?", q.Role)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		logger.Log.Error("查询用户失败", zap.Error(err))
+		return nil, 0, errors.New("系统内部错误，请稍后再试")
+	}
+	users := []AdminUserView{}
+	err := db.Select("id", "username", "phone", "email", "status", "role", "created_at").
+		Order("id DESC").
+		Offset((q.Page - 1) * q.Size).
+		Limit(q.Size).
+		Find(&users).Error
+	if err != nil {
+		logger.Log.Error("查询用户失败", zap.Error(err))
+		return nil, 0, errors.New("系统内部错误，请稍后再试")
+	}
+	return users, total, nil
+}
+
+// SetUserStatus 禁用/启用用户，禁用时踢下线
+func SetUserStatus(operatorID, uid uint, status int) error {
+	if status != 1 && status != 2 {
+		return errors.New("状态只能是 1(正常) 或 2(禁用)")
+	}
+	if operatorID == uid {
+		return ErrModifySelf
+	}
+	if err := updateUser(uid, map[string]interface{}{"status": status}); err != nil {
+		return err
+	}
+	logger.Log.Info("修改用户状态",
+		zap.Uint("operator", operatorID),
+		zap.Uint("uid", uid),
+		zap.Int("status", status),
+	)
+	if status == 2 {
+		return RevokeUserSessions(uid)
+	}
+	return nil
+}
+
+// SetUserRole 分配角色，token 中携带角色，变更后踢下线让用户重新登录
+func SetUserRole(operatorID, uid uint, role string) error {
+	if !model.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if operatorID == uid {
+		return ErrModifySelf
+	}
+	// is_admin 同步更新，避免启动时的角色迁移把降级的管理员重新提升
+	if err := updateUser(uid, map[string]interface{}{"role": role, "is_admin": role == model.RoleAdmin}); err != nil {
+		return err
+	}
+	logger.Log.Info("修改用户角色",
+		zap.Uint("operator", operatorID),
+		zap.Uint("uid", uid),
+		zap.String("role", role),
+	)
+	return RevokeUserSessions(uid)
+}
+
+// AssignRoleByUsername 按用户名分配角色，用于命令行初始化第一个管理员
+// 新角色在用户下次登录后生效
+func AssignRoleByUsername(username, role string) error {
+	if !model.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	var user model.User
+	if err := database.DB.Select("id").Where("username = ?", username).First(&user).Error; err != nil {
+		return ErrUserNotFound
+	}
+	return updateUser(user.ID, map[string]interface{}{"role": role, "is_admin": role == model.RoleAdmin})
+}
+
+// MigrateUserRoles 把旧的 is_admin 标记迁移为 admin 角色（可重复执行）
+func MigrateUserRoles() error {
+	result := database.DB.Model(&model.User{}).
+		Where("is_admin = ? AND role = ?", true, model.RoleUser).
+		Update("role", model.RoleAdmin)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Info("管理员角色迁移完成", zap.Int64("count", result.RowsAffected))
+	}
+	return nil
+}
+
+// updateUser 更新用户字段，用户不存在时返回 ErrUserNotFound
+func updateUser(uid uint, values map[string]interface{}) error {
+	result := database.DB.Model(&model.User{}).Where("id = ?", uid).Updates(values)
+	if result.Error != nil {
+		logger.Log.Error("更新用户失败", zap.Uint("uid", uid), zap.Error(result.Error))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	if result.RowsAffected == 0 {
+		// 值未变化时 MySQL 也返回 0，需要区分用户是否存在
+		var count int64
+		database.DB.Model(&model.User{}).Where("id = ?", uid).Count(&count)
+		if count == 0 {
+			return ErrUserNotFound
+		}
+	}
+	return nil
+}
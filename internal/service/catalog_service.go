@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/cache"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+	"seckill/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+// 前台商品查询：本地 LRU → Redis → MySQL 逐级回源（cache-aside）
+// 1. 同一个商品的并发回源通过 singleflight 合并，防止缓存击穿
+// 2. 商品不存在时缓存空值，防止缓存穿透
+// 3. Redis 缓存时间加随机抖动，防止缓存雪崩
+// 缓存中只有商品和场次的静态信息，剩余库存每次从 Redis 场次库存读取
+
+// 场次状态
+const (
+	SaleUpcoming = "upcoming" // 未开始
+	SaleOnSale   = "on_sale"  // 进行中
+	SaleSoldOut  = "sold_out" // 已抢光
+)
+
+// 商品缓存 key
+const (
+	productCacheKeyFmt = "seckill:cache:product:%d"
+	onSaleCacheKey     = "seckill:cache:products:on_sale"
+)
+
+var (
+	catalogGroup singleflight.Group
+
+	localOnce    sync.Once
+	productLocal *cache.LRU[uint, *ProductDetail] // 值为 nil 表示商品不存在
+	onSaleLocal  *cache.LRU[string, []uint]
+)
+
+// ProductDetail 前台商品详情
+type ProductDetail struct {
+	ID          uint           `json:"id"`
+	Name        string         `json:"name"`
+	Price       money.Money    `json:"price"`
+	Description string         `json:"description"`
+	ImageURL    string         `json:"image_url"`
+	Images      []string       `json:"images"`
+	Sessions    []*SaleSession `json:"sessions"` // 未结束的场次，按开始时间排序
+}
+
+// SaleSession 商品的秒杀场次
+type SaleSession struct {
+	ID           uint        `json:"id"`
+	SeckillPrice money.Money `json:"seckill_price"`
+	TotalStock   int         `json:"total_stock"`
+	Stock        int         `json:"stock"` // 剩余可抢库存
+	PerUserLimit int         `json:"per_user_limit"`
+	StartTime    time.Time   `json:"start_time"`
+	EndTime      time.Time   `json:"end_time"`
+	Status       string      `json:"status"` // upcoming/on_sale/sold_out
+}
+
+func productCacheKey(id uint) string {
+	return fmt.Sprintf(productCacheKeyFmt, id)
+}
+
+func initLocalCache() {
+	localOnce.Do(func() {
+		productLocal = cache.NewLRU[uint, *ProductDetail](config.Get().Cache.LocalSize)
+		onSaleLocal = cache.NewLRU[string, []uint](1)
+	})
+}
+
+// GetProductDetail 商品详情（含实时库存）
+func GetProductDetail(id uint) (*ProductDetail, error) {
+	ctx := context.Background()
+	d, err := loadProductDetail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrProductNotFound
+	}
+	result := withLiveStock(ctx, []*ProductDetail{d}, time.Now())
+	return result[0], nil
+}
+
+// ListOnSaleProducts 分页查询有未结束场次的商品（含实时库存）
+func ListOnSaleProducts(page, size int) ([]*ProductDetail, int, error) {
+	ctx := context.Background()
+	ids, err := loadOnSaleIDs(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	total := len(ids)
+	start := min((page-1)*size, total)
+	end := min(start+size, total)
+
+	details := make([]*ProductDetail, 0, end-start)
+	for _, id := range ids[start:end] {
+		d, err := loadProductDetail(ctx, id)
+		if err != nil {
+			return nil, 0, err
+		}
+		if d != nil {
+			details = append(details, d)
+		}
+	}
+	return withLiveStock(ctx, details, time.Now()), total, nil
+}
+
+// InvalidateProductCache 删除商品缓存，后台修改商品或场次后调用
+// 只能删除本副本的本地缓存，其他副本等待 local_ttl 过期
+func InvalidateProductCache(ids ...uint) {
+	initLocalCache()
+	ctx := context.Background()
+	keys := []string{onSaleCacheKey}
+	for _, id := range ids {
+		keys = append(keys, productCacheKey(id))
+		productLocal.Delete(id)
+	}
+	onSaleLocal.Delete(onSaleCacheKey)
+	if err := redis.Client.Del(ctx, keys...).Err(); err != nil {
+		logger.Log.Warn("删除商品缓存失败", zap.Uints("pids", ids), zap.Error(err))
+	}
+}
+
+// loadProductDetail 读取商品静态信息，商品不存在时返回 nil
+func loadProductDetail(ctx context.Context, id uint) (*ProductDetail, error) {
+	initLocalCache()
+	if d, ok := productLocal.Get(id); ok {
+		return d, nil
+	}
+
+	v, err, _ := catalogGroup.Do(productCacheKey(id), func() (interface{}, error) {
+		cfg := config.Get().Cache
+		key := productCacheKey(id)
+
+		// 1. Redis 缓存，空字符串表示商品不存在
+		data, err := redis.Client.Get(ctx, key).Bytes()
+		if err == nil {
+			var d *ProductDetail
+			if len(data) > 0 {
+				d = new(ProductDetail)
+				if err := json.Unmarshal(data, d); err != nil {
+					return nil, err
+				}
+			}
+			productLocal.Set(id, d, cfg.LocalTTL)
+			return d, nil
+		}
+		if !errors.Is(err, goredis.Nil) {
+			// Redis 不可用时直接回源，singleflight 限制每个商品同时只有一个查询
+			logger.Log.Warn("读取商品缓存失败", zap.Uint("pid", id), zap.Error(err))
+		}
+
+		// 2. 回源 MySQL
+		d, err := queryProductDetail(id)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			redis.Client.Set(ctx, key, "", cfg.NullTTL)
+		} else if data, err := json.Marshal(d); err == nil {
+			redis.Client.Set(ctx, key, data, jitter(cfg.ProductTTL))
+		}
+		productLocal.Set(id, d, cfg.LocalTTL)
+		return d, nil
+	})
+	if err != nil {
+		logger.Log.Error("查询商品详情失败", zap.Uint("pid", id), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return v.(*ProductDetail), nil
+}
+
+// queryProductDetail 从 MySQL 查询商品和未结束的场次
+func queryProductDetail(id uint) (*ProductDetail, error) {
+	var p model.Product
+	err := database.DB.First(&p, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []model.SeckillSession
+	err = database.DB.
+		Where("product_id = ? AND end_time > ?", id, time.Now()).
+		Order("start_time").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	d := &ProductDetail{
+		ID:          p.ID,
+		Name:        p.Name,
+		Price:       p.Price,
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		Images:      p.Images,
+		Sessions:    make([]*SaleSession, 0, len(sessions)),
+	}
+	if d.Images == nil {
+		d.Images = []string{}
+	}
+	for _, s := range sessions {
+		d.Sessions = append(d.Sessions, &SaleSession{
+			ID:           s.ID,
+			SeckillPrice: s.SeckillPrice,
+			TotalStock:   s.TotalStock,
+			Stock:        s.Stock,
+			PerUserLimit: s.PerUserLimit,
+			StartTime:    s.StartTime,
+			EndTime:      s.EndTime,
+		})
+	}
+	return d, nil
+}
+
+// loadOnSaleIDs 有未结束场次的商品ID，按ID倒序
+func loadOnSaleIDs(ctx context.Context) ([]uint, error) {
+	initLocalCache()
+	if ids, ok := onSaleLocal.Get(onSaleCacheKey); ok {
+		return ids, nil
+	}
+
+	v, err, _ := catalogGroup.Do(onSaleCacheKey, func() (interface{}, error) {
+		cfg := config.Get().Cache
+		var ids []uint
+		data, err := redis.Client.Get(ctx, onSaleCacheKey).Bytes()
+		if err == nil && json.Unmarshal(data, &ids) == nil {
+			onSaleLocal.Set(onSaleCacheKey, ids, cfg.LocalTTL)
+			return ids, nil
+		}
+		if err != nil && !errors.Is(err, goredis.Nil) {
+			logger.Log.Warn("读取在售商品缓存失败", zap.Error(err))
+		}
+
+		err = database.DB.Model(&model.SeckillSession{}).
+			Joins("JOIN products ON products.id = seckill_sessions.product_id AND products.deleted_at IS NULL").
+			Where("seckill_sessions.end_time > ?", time.Now()).
+			Distinct().
+			Order("seckill_sessions.product_id DESC").
+			Pluck("seckill_sessions.product_id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		if ids == nil {
+			ids = []uint{}
+		}
+		if data, err := json.Marshal(ids); err == nil {
+			redis.Client.Set(ctx, onSaleCacheKey, data, jitter(cfg.ListTTL))
+		}
+		onSaleLocal.Set(onSaleCacheKey, ids, cfg.LocalTTL)
+		return ids, nil
+	})
+	if err != nil {
+		logger.Log.Error("查询在售商品失败", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return v.([]uint), nil
+}
+
+// withLiveStock 复制缓存中的商品，去掉已结束的场次，填入 Redis 实时库存和场次状态
+// 缓存对象被多个请求共享，不能直接修改
+func withLiveStock(ctx context.Context, details []*ProductDetail, now time.Time) []*ProductDetail {
+	result := make([]*ProductDetail, len(details))
+	var sessions []*SaleSession
+	for i, d := range details {
+		cp := *d
+		cp.Sessions = make([]*SaleSession, 0, len(d.Sessions))
+		for _, s := range d.Sessions {
+			if !now.Before(s.EndTime) {
+				continue
+			}
+			sc := *s
+			cp.Sessions = append(cp.Sessions, &sc)
+			sessions = append(sessions, &sc)
+		}
+		result[i] = &cp
+	}
+
+	// 尚未预热的场次没有 Redis 库存，使用 MySQL 库存
+	if len(sessions) > 0 {
+		ids := make([]uint, len(sessions))
+		for i, s := range sessions {
+			ids[i] = s.ID
+		}
+		stocks, err := redis.SessionStocks(ctx, ids)
+		if err != nil {
+			logger.Log.Warn("读取场次库存失败", zap.Error(err))
+		}
+		for _, s := range sessions {
+			if stock, ok := stocks[s.ID]; ok {
+				s.Stock = int(stock)
+			}
+		}
+	}
+	for _, s := range sessions {
+		switch {
+		case now.Before(s.StartTime):
+			s.Status = SaleUpcoming
+		case s.Stock <= 0:
+			s.Status = SaleSoldOut
+		default:
+			s.Status = SaleOnSale
+		}
+	}
+	return result
+}
+
+// jitter 缓存时间加上 10% 以内的随机值
+func jitter(ttl time.Duration) time.Duration {
+	return ttl + rand.N(ttl/10+1)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 场次清理：场次结束后按 MySQL 库存归档最终售出数量，再删除 Redis 中的场次 key
+// 结束后等待 archive_delay（队列中的下单消息消费完）+ 支付超时（未支付订单取消并归还库存）再归档
+// 仍有未支付订单的场次推迟到下一轮，保证归档后的售出数量不会再因为取消订单而变化
+// 所有场次 key 都带有过期时间（见 writeSessionToRedis），这里只是提前回收并保留统计
+
+// cleanupLockKey 多副本部署时只有拿到锁的副本执行清理
+const cleanupLockKey = "seckill:lock:cleanup"
+
+// ArchiveEndedSessions 归档已结束的场次，返回归档的场次数
+func ArchiveEndedSessions(ctx context.Context) (int, error) {
+	cfg := config.Get().Cleanup
+	total := 0
+	var sessions []model.SeckillSession
+	result := database.DB.
+		Select("id", "total_stock", "stock").
+		Where("end_time <= ? AND archived_at IS NULL", time.Now().Add(-archiveDelay())).
+		FindInBatches(&sessions, cfg.BatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range sessions {
+				pending, err := hasUnpaidOrders(sessions[i].ID)
+				if err != nil {
+					return err
+				}
+				if pending {
+					continue
+				}
+				if err := archiveSession(ctx, &sessions[i]); err != nil {
+					return err
+				}
+				total++
+			}
+			return nil
+		})
+	return total, result.Error
+}
+
+// archiveDelay 场次结束多久后归档
+// 最后一批订单在结束后 archive_delay 内创建，再经过支付超时和兜底扫描（sweepGrace + 扫描间隔）全部支付或取消
+func archiveDelay() time.Duration {
+	cfg := config.Get()
+	return cfg.Cleanup.ArchiveDelay + cfg.Order.PayTimeout + sweepGrace + cfg.Order.SweepInterval
+}
+
+// hasUnpaidOrders 场次是否还有待支付的订单（取消后会归还库存，不能归档）
+func hasUnpaidOrders(sessionID uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&model.Order{}).
+		Where("session_id = ? AND status IN ?", sessionID, []model.OrderStatus{model.OrderCreated, model.OrderUnpaid}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// archiveSession 归档单个场次
+// 售出数量以 MySQL 库存差为准：库存只在订单创建、取消、退款时变化，加锁重新读取后不会再变
+// Redis 扣减包含下单失败的请求，只记录在日志中用于排查
+func archiveSession(ctx context.Context, s *model.SeckillSession) error {
+	redisStock, redisErr := redis.Client.Get(ctx, redis.SessionStockKey(s.ID)).Int()
+
+	var sold, buyers int
+	archived := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "total_stock", "stock", "archived_at").
+			First(s, s.ID).Error; err != nil {
+			return err
+		}
+		if s.ArchivedAt != nil {
+			return nil
+		}
+		var count int64
+		err := tx.Model(&model.Order{}).
+			Where("session_id = ? AND status <> ?", s.ID, model.OrderCancelled).
+			Distinct("user_id").
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		sold, buyers = s.TotalStock-s.Stock, int(count)
+		archived = true
+		return tx.Model(s).Updates(map[string]interface{}{
+			"sold_count":  sold,
+			"buyer_count": buyers,
+			"archived_at": time.Now(),
+		}).Error
+	})
+	if err != nil || !archived {
+		return err
+	}
+	// 删除失败不影响归档，key 到期后会自动过期
+	if err := redis.Client.Del(ctx, redis.SessionKeys(s.ID)...).Err(); err != nil {
+		logger.Log.Warn("删除场次 key 失败", zap.Uint("session_id", s.ID), zap.Error(err))
+	}
+	fields := []zap.Field{
+		zap.Uint("session_id", s.ID),
+		zap.Int("sold", sold),
+		zap.Int("buyers", buyers),
+	}
+	if redisErr == nil {
+		fields = append(fields, zap.Int("redis_sold", s.TotalStock-redisStock))
+	}
+	logger.Log.Info("场次已归档", fields...)
+	return nil
+}
+
+// StartCleanupScheduler 启动定时清理
+func StartCleanupScheduler() {
+	startScheduledJob(scheduledJob{
+		name:    "场次清理",
+		lockKey: cleanupLockKey,
+		settings: func() (bool, time.Duration) {
+			cfg := config.Get().Cleanup
+			return cfg.Enabled, cfg.Interval
+		},
+		run: runCleanup,
+	})
+}
+
+// runCleanup 执行一轮清理
+func runCleanup(ctx context.Context) {
+	n, err := ArchiveEndedSessions(ctx)
+	if err != nil {
+		logger.Log.Error("场次归档失败", zap.Int("archived", n), zap.Error(err))
+		return
+	}
+	if n > 0 {
+		logger.Log.Info("场次清理完成", zap.Int("archived", n))
+	}
+}
@@ -1,18 +1,40 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"seckill/internal/model"
+	"seckill/pkg/breaker"
+	"seckill/pkg/config"
 	"seckill/pkg/database"
 	"seckill/pkg/logger"
 	"seckill/pkg/rabbitmq"
+	"seckill/pkg/redis"
 	"seckill/pkg/snowflake"
+	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// 业务失败，不计入熔断，重试也不会成功，直接确认消息
+var (
+	errStockNotEnough = errors.New("库存不足")
+	errOverLimit      = errors.New("超出限购数量")
+	errNoSession      = errors.New("场次不存在")
+)
+
+// maxOrderRetries 数据库等基础设施错误时下单消息的最大重试次数，超过后判定下单失败
+const maxOrderRetries = 3
+
+// isBusinessError 是否为业务失败
+func isBusinessError(err error) bool {
+	return errors.Is(err, errStockNotEnough) || errors.Is(err, errOverLimit) || errors.Is(err, errNoSession)
+}
+
 //处理消息队列的消费者
 //流程：连上 RabbitMQ -> 监听队列 -> 收到消息 -> 解析json -> 开启数据库事务 -> 扣库存 -> 创建订单 -> ack确认
 
@@ -41,41 +63,187 @@ func StartConsumer() {
 			//4、解析json
 			var msg rabbitmq.OrderMessage
 			json.Unmarshal(d.Body, &msg)
-			logger.Log.Info("收到消息", zap.Int64("uid", msg.UserID), zap.Int64("pid", msg.ProductID))
+			if msg.OrderNum == "" {
+				//升级前投递的消息没有订单号，这里补一个
+				msg.OrderNum = snowflake.GenerateID()
+			}
+			logger.Log.Info("收到消息",
+				zap.Int64("uid", msg.UserID),
+				zap.Int64("sid", msg.SessionID),
+				zap.String("order_num", msg.OrderNum),
+			)
 			//5、处理下单逻辑(写入mysql)
-			err := createOrderInDB(msg.UserID, msg.ProductID)
+			dbDone, err := breaker.Get(breaker.MySQL).Allow()
 			if err != nil {
-				//失败处理
-				logger.Log.Error("下单失败", zap.Error(err))
-				//d.Reject(true) //退回队列重试
-			} else {
-				//处理成功 发送ack
+				//MySQL 熔断中，消息放回队列，稍后重试
+				logger.Log.Warn("MySQL 熔断中，消息重新入队", zap.Int64("uid", msg.UserID))
+				time.Sleep(time.Second)
+				d.Nack(false, true)
+				continue
+			}
+			err = createOrderInDB(msg.UserID, msg.SessionID, msg.OrderNum, msg.AddressID)
+			dbDone(err == nil || isBusinessError(err))
+			switch {
+			case err == nil:
+				//处理成功 投递支付超时消息 更新抢购结果并推送 发送ack
+				scheduleOrderTimeout(msg.OrderNum)
+				reportOrderResult(msg.UserID, msg.SessionID, msg.OrderNum, ResultCreated, "")
+				d.Ack(false)
+			case isBusinessError(err):
+				//业务失败，重试也不会成功，归还秒杀脚本的预扣并记录失败原因后直接确认
+				//MySQL 库存不足时只归还限购名额，不归还 Redis 库存
+				logger.Log.Warn("下单失败", zap.Int64("uid", msg.UserID), zap.Int64("sid", msg.SessionID), zap.Error(err))
+				releaseReservation(&msg, errors.Is(err, errStockNotEnough))
+				reportOrderResult(msg.UserID, msg.SessionID, msg.OrderNum, ResultFailed, err.Error())
 				d.Ack(false)
+			default:
+				//基础设施错误（数据库超时等），退避后重新投递，超过重试次数判定失败
+				retryOrderMessage(d, &msg, err)
 			}
 		}
 	}()
 }
 
-// createOrderInDB 数据库事务操作 扣减mysql库存和创建订单
-func createOrderInDB(uid int64, pid int64) error {
+// retryOrderMessage 创建订单遇到基础设施错误时重新投递消息
+// 重试次数记录在消息头中，超过 maxOrderRetries 后归还 Redis 库存和限购名额并通知用户下单失败
+func retryOrderMessage(d amqp.Delivery, msg *rabbitmq.OrderMessage, cause error) {
+	retries := deliveryRetries(d)
+	fields := []zap.Field{
+		zap.Int64("uid", msg.UserID),
+		zap.String("order_num", msg.OrderNum),
+		zap.Int32("retries", retries),
+		zap.Error(cause),
+	}
+	if retries < maxOrderRetries {
+		logger.Log.Warn("下单失败，稍后重试", fields...)
+		//退避，避免数据库故障时消息在队列中空转
+		time.Sleep(time.Duration(retries+1) * time.Second)
+		if err := rabbitmq.RetrySeckillMessage(msg, retries+1); err != nil {
+			//重新投递失败，退回队列（不计重试次数）
+			logger.Log.Error("重新投递下单消息失败，退回队列", zap.String("order_num", msg.OrderNum), zap.Error(err))
+			d.Nack(false, true)
+			return
+		}
+		d.Ack(false)
+		return
+	}
+
+	logger.Log.Error("下单失败，超过重试次数", fields...)
+	releaseReservation(msg, false)
+	reportOrderResult(msg.UserID, msg.SessionID, msg.OrderNum, ResultFailed, "订单创建失败")
+	d.Ack(false)
+}
+
+// releaseReservation 订单没有创建时归还秒杀脚本预扣的 Redis 库存和限购名额
+// keepStock 为 true 时只归还限购名额，抢购结果由调用方更新
+func releaseReservation(msg *rabbitmq.OrderMessage, keepStock bool) {
+	quotaOnly := 0
+	if keepStock {
+		quotaOnly = 1
+	}
+	keys := redis.SessionKeys(uint(msg.SessionID))
+	err := redis.ReleaseScript.Run(context.Background(), redis.Client,
+		[]string{keys[0], keys[1], keys[3]},
+		msg.UserID, 1, "", 0, quotaOnly,
+	).Err()
+	if err != nil {
+		logger.Log.Error("归还 Redis 库存失败", zap.String("order_num", msg.OrderNum), zap.Error(err))
+	}
+}
+
+// deliveryRetries 消息已重试的次数
+func deliveryRetries(d amqp.Delivery) int32 {
+	switch v := d.Headers[rabbitmq.RetryHeader].(type) {
+	case int32:
+		return v
+	case int64:
+		return int32(v)
+	case int:
+		return int32(v)
+	}
+	return 0
+}
+
+// createOrderInDB 数据库事务操作 扣减场次库存和创建订单
+// 按订单号幂等：消息重复投递时订单已存在，直接返回成功
+func createOrderInDB(uid int64, sid int64, orderNum string, addressID uint) error {
 	return database.DB.Transaction(func(tx *gorm.DB) error {
-		//1、扣减库存
-		result := tx.Model(&model.Product{}).Where("id = ? AND stock > 0", pid).
+		//0、订单已创建
+		var exists int64
+		if err := tx.Model(&model.Order{}).Where("order_num = ?", orderNum).Count(&exists).Error; err != nil {
+			return err
+		}
+		if exists > 0 {
+			return nil
+		}
+		//1、查询场次和商品（加锁，后台修改价格时等待下单事务结束，快照不会读到一半的修改）
+		var session model.SeckillSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sid).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNoSession
+			}
+			return err
+		}
+		var product model.Product
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "SHARE"}).
+			Select("id", "name", "image_url", "price").
+			First(&product, session.ProductID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errNoSession
+			}
+			return err
+		}
+		//2、限购校验（消息重复投递时避免超买）
+		var count int64
+		err = tx.Model(&model.Order{}).
+			Where("user_id = ? AND session_id = ? AND status <> ?", uid, sid, model.OrderCancelled).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= int64(session.PerUserLimit) {
+			return errOverLimit
+		}
+		//3、扣减库存
+		result := tx.Model(&model.SeckillSession{}).Where("id = ? AND stock > 0", sid).
 			Update("stock", gorm.Expr("stock - ?", 1))
+		if result.Error != nil {
+			return result.Error
+		}
 		if result.RowsAffected == 0 {
-			return fmt.Errorf("库存不足")
+			return errStockNotEnough
 		}
-		//2、创建订单
+		//4、创建订单（创建后直接进入未支付，超时自动取消），记录商品和价格快照
+		const quantity = 1
 		order := model.Order{
 			UserID:    uint(uid),
-			ProductID: uint(pid),
-			Status:    1, //已支付
-			//订单号生成 雪花算法
-			OrderNum: snowflake.GenerateID(),
+			ProductID: session.ProductID,
+			SessionID: session.ID,
+			Status:    model.OrderUnpaid,
+			//订单号在抢购时由雪花算法生成
+			OrderNum:    orderNum,
+			PayDeadline: time.Now().Add(config.Get().Order.PayTimeout),
+
+			ProductName:  product.Name,
+			ProductImage: product.ImageURL,
+			UnitPrice:    product.Price,
+			SeckillPrice: session.SeckillPrice,
+			Quantity:     quantity,
+			TotalAmount:  session.SeckillPrice.Mul(quantity),
+		}
+		//5、收货地址快照（没有指定地址也没有默认地址时留空，发货前由用户或客服补充，见 SetOrderAddress）
+		address, err := orderAddress(tx, uint(uid), addressID)
+		if err != nil {
+			return err
+		}
+		if address != nil {
+			order.AddressID = address.ID
+			order.Shipping = address.AddressSnapshot
 		}
 		if err := tx.Create(&order).Error; err != nil {
 			return err
 		}
-		return nil
+		return recordOrderStatus(tx, order.ID, model.OrderCreated, model.OrderUnpaid, actorSystem, "抢购下单")
 	})
 }
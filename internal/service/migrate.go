@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+	"seckill/pkg/redis"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 数据迁移：AutoMigrate 只会加表加列，删列、删索引和数据搬迁在这里完成
+// 每一步都可以重复执行，已经迁移过的会直接跳过
+
+// legacyActivityName 迁移旧商品秒杀字段时创建的活动
+const legacyActivityName = "历史秒杀（迁移）"
+
+// RunMigrations 执行数据迁移，需要在 AutoMigrate 之后调用
+func RunMigrations() error {
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"管理员角色", MigrateUserRoles},
+		{"商品秒杀字段迁移到场次", migrateProductSessions},
+		{"删除订单旧唯一索引", dropOrderProductIndex},
+		{"订单商品和价格快照", backfillOrderSnapshots},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateProductSessions 把 products 表上的秒杀价、库存、时间迁移为场次，然后删除旧列
+// 同时把旧的 Redis 库存和已购用户迁移到场次 key 下
+func migrateProductSessions() error {
+	m := database.DB.Migrator()
+	legacyColumns := []string{"seckill_price", "stock", "start_time", "end_time"}
+	if !m.HasColumn(&model.Product{}, "stock") {
+		return nil
+	}
+
+	var rows []struct {
+		ID           uint
+		SeckillPrice float64
+		Stock        int
+		StartTime    time.Time
+		EndTime      time.Time
+	}
+	err := database.DB.Table("products").
+		Select("id, seckill_price, stock, start_time, end_time").
+		Where("deleted_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	var sessions []model.SeckillSession
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		activity := model.SeckillActivity{Name: legacyActivityName}
+		if err := tx.Where("name = ?", legacyActivityName).FirstOrCreate(&activity).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			// 上次迁移中断时已经建好的场次跳过
+			var exists int64
+			err := tx.Model(&model.SeckillSession{}).
+				Where("activity_id = ? AND product_id = ?", activity.ID, row.ID).
+				Count(&exists).Error
+			if err != nil {
+				return err
+			}
+			if exists > 0 {
+				continue
+			}
+			var sold int64
+			if err := tx.Model(&model.Order{}).Where("product_id = ?", row.ID).Count(&sold).Error; err != nil {
+				return err
+			}
+			s := model.SeckillSession{
+				ActivityID:   activity.ID,
+				ProductID:    row.ID,
+				SeckillPrice: money.FromYuan(row.SeckillPrice), // 旧列是 decimal（元）
+				TotalStock:   row.Stock + int(sold),
+				Stock:        row.Stock,
+				PerUserLimit: 1, // 旧逻辑每人限购一件
+				StartTime:    row.StartTime,
+				EndTime:      row.EndTime,
+			}
+			if err := tx.Create(&s).Error; err != nil {
+				return err
+			}
+			err = tx.Model(&model.Order{}).
+				Where("product_id = ? AND session_id = 0", row.ID).
+				Update("session_id", s.ID).Error
+			if err != nil {
+				return err
+			}
+			sessions = append(sessions, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Redis 迁移失败不影响数据库迁移，场次库存可由预热重新写入
+	for i := range sessions {
+		if err := migrateLegacyRedisKeys(&sessions[i]); err != nil {
+			logger.Log.Warn("迁移旧 Redis 库存失败", zap.Uint("pid", sessions[i].ProductID), zap.Error(err))
+		}
+	}
+
+	for _, col := range legacyColumns {
+		if m.HasColumn(&model.Product{}, col) {
+			if err := m.DropColumn(&model.Product{}, col); err != nil {
+				return err
+			}
+		}
+	}
+	logger.Log.Info("商品秒杀字段已迁移到场次", zap.Int("sessions", len(sessions)))
+	return nil
+}
+
+// migrateLegacyRedisKeys 旧 key（seckill:stock:{pid}、seckill:bought:{pid}）迁移到场次 key
+// Redis 中的库存比 MySQL 更新（包含尚未落库的抢购），存在时以它为准
+func migrateLegacyRedisKeys(s *model.SeckillSession) error {
+	ctx := context.Background()
+	oldStock := fmt.Sprintf("seckill:stock:%d", s.ProductID)
+	oldBought := fmt.Sprintf("seckill:bought:%d", s.ProductID)
+	oldWindow := fmt.Sprintf("seckill:window:%d", s.ProductID)
+
+	if stock, err := redis.Client.Get(ctx, oldStock).Int64(); err == nil {
+		if err := redis.Client.Set(ctx, redis.SessionStockKey(s.ID), stock, 0).Err(); err != nil {
+			return err
+		}
+	}
+	users, err := redis.Client.SMembers(ctx, oldBought).Result()
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		values := make([]interface{}, 0, len(users)*2)
+		for _, uid := range users {
+			values = append(values, uid, 1)
+		}
+		if err := redis.Client.HSet(ctx, redis.SessionBoughtKey(s.ID), values...).Err(); err != nil {
+			return err
+		}
+	}
+	// 最后写入场次信息和过期时间，旧库存已迁移时不会被覆盖
+	pipe := redis.Client.Pipeline()
+	writeSessionToRedis(ctx, pipe, s, false)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	return redis.Client.Del(ctx, oldStock, oldBought, oldWindow).Err()
+}
+
+// dropOrderProductIndex 删除订单 (user_id, product_id) 唯一索引
+// 一个商品可以出现在多个场次，限购改为按场次校验
+func dropOrderProductIndex() error {
+	m := database.DB.Migrator()
+	if !m.HasIndex(&model.Order{}, "idx_user_product") {
+		return nil
+	}
+	return m.DropIndex(&model.Order{}, "idx_user_product")
+}
+
+// backfillOrderSnapshots 为快照字段上线前创建的订单补充商品和价格快照
+// 只能取当前的商品和场次数据，历史订单之前改过价的无法还原
+func backfillOrderSnapshots() error {
+	result := database.DB.Exec(`
+		UPDATE orders o
+		JOIN products p ON p.id = o.product_id
+		JOIN seckill_sessions s ON s.id = o.session_id
+		SET o.product_name = p.name,
+			o.product_image = COALESCE(p.image_url, ''),
+			o.unit_price = p.price,
+			o.seckill_price = s.seckill_price,
+			o.quantity = 1,
+			o.total_amount = s.seckill_price
+		WHERE o.product_name = ''`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Info("已补充订单快照", zap.Int64("orders", result.RowsAffected))
+	}
+	return nil
+}
+
+// moneyColumns 由 decimal（元）改为 bigint（分）的金额列
+var moneyColumns = []struct{ table, column string }{
+	{"products", "price"},
+	{"seckill_sessions", "seckill_price"},
+	{"orders", "unit_price"},
+	{"orders", "seckill_price"},
+	{"orders", "total_amount"},
+	{"payments", "amount"},
+}
+
+// MigrateMoneyColumns 把金额列从 decimal（元）转换为 bigint（分），需要在 AutoMigrate 之前调用
+// AutoMigrate 直接改列类型会截断小数，这里先写入临时列再一次性替换
+// 中途失败可以重新执行：临时列按原列重新计算，原列只在最后一步的 ALTER 中原子替换
+func MigrateMoneyColumns() error {
+	m := database.DB.Migrator()
+	for _, c := range moneyColumns {
+		if !m.HasTable(c.table) {
+			continue
+		}
+		types, err := m.ColumnTypes(c.table)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+		isDecimal := false
+		for _, t := range types {
+			if t.Name() == c.column && strings.EqualFold(t.DatabaseTypeName(), "decimal") {
+				isDecimal = true
+			}
+		}
+		if !isDecimal {
+			continue
+		}
+
+		tmp := c.column + "_cents"
+		if !m.HasColumn(c.table, tmp) {
+			sql := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` BIGINT NOT NULL DEFAULT 0", c.table, tmp)
+			if err := database.DB.Exec(sql).Error; err != nil {
+				return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+			}
+		}
+		sql := fmt.Sprintf("UPDATE `%s` SET `%s` = ROUND(`%s` * 100)", c.table, tmp, c.column)
+		if err := database.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+		sql = fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`, CHANGE COLUMN `%s` `%s` BIGINT NOT NULL DEFAULT 0",
+			c.table, c.column, tmp, c.column)
+		if err := database.DB.Exec(sql).Error; err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+		logger.Log.Info("金额列已转换为分", zap.String("table", c.table), zap.String("column", c.column))
+	}
+	return nil
+}
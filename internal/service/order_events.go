@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"seckill/pkg/config"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 订单结果实时推送（SSE）
+// 1. 消费者处理完订单后把事件写入用户的 Redis Stream，Stream ID 作为事件ID
+// 2. 再通过 Pub/Sub 广播到所有副本，持有该用户连接的副本推送给客户端
+// 3. 客户端断线重连时带上 Last-Event-ID，从 Stream 中补发之后的事件
+
+var ErrInvalidEventID = errors.New("无效的事件ID")
+
+// OrderEvent 订单结果事件
+type OrderEvent struct {
+	ID     string `json:"id"`   // Stream ID，作为 SSE 事件ID
+	UserID uint   `json:"uid"`  // 接收用户
+	Data   string `json:"data"` // 事件内容（JSON）
+}
+
+// orderEventData 推送给客户端的事件内容
+type orderEventData struct {
+	SessionID int64 `json:"session_id"`
+	OrderResult
+}
+
+// eventHub 本副本上的事件订阅，按用户分组
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[uint]map[chan OrderEvent]struct{}
+}
+
+var hub = &eventHub{subs: make(map[uint]map[chan OrderEvent]struct{})}
+
+// reportOrderResult 更新抢购结果并推送事件
+func reportOrderResult(uid, sid int64, orderNum, status, reason string) {
+	setOrderResult(uid, sid, orderNum, status, reason)
+	publishOrderEvent(uid, sid, orderNum, status, reason)
+}
+
+// publishOrderEvent 写入用户事件流并广播，失败只记录日志（客户端可以轮询结果接口）
+func publishOrderEvent(uid, sid int64, orderNum, status, reason string) {
+	cfg := config.Get().Events
+	ctx := context.Background()
+	key := redis.UserEventsKey(uint(uid))
+
+	data, _ := json.Marshal(orderEventData{SessionID: sid, OrderResult: newOrderResult(orderNum, status, reason)})
+
+	id, err := redis.Client.XAdd(ctx, &goredis.XAddArgs{
+		Stream: key,
+		MaxLen: cfg.StreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Result()
+	if err != nil {
+		logger.Log.Warn("写入订单事件失败", zap.Int64("uid", uid), zap.String("order_num", orderNum), zap.Error(err))
+		return
+	}
+	redis.Client.Expire(ctx, key, cfg.StreamTTL)
+
+	msg, _ := json.Marshal(OrderEvent{ID: id, UserID: uint(uid), Data: string(data)})
+	if err := redis.Client.Publish(ctx, redis.OrderEventsChannel, msg).Err(); err != nil {
+		logger.Log.Warn("广播订单事件失败", zap.Int64("uid", uid), zap.String("order_num", orderNum), zap.Error(err))
+	}
+}
+
+// StartOrderEventHub 订阅广播频道，把事件分发给本副本上的连接
+// go-redis 在连接断开后会自动重新订阅，期间丢失的事件由客户端重连时补发
+func StartOrderEventHub() {
+	pubsub := redis.Client.Subscribe(context.Background(), redis.OrderEventsChannel)
+	go func() {
+		for msg := range pubsub.Channel() {
+			var e OrderEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				logger.Log.Warn("解析订单事件失败", zap.Error(err))
+				continue
+			}
+			hub.dispatch(e)
+		}
+	}()
+}
+
+// SubscribeOrderEvents 订阅用户在本副本上的实时事件，返回取消订阅函数
+// 客户端处理过慢导致缓冲区满时通道会被关闭，客户端重连后按 Last-Event-ID 补发
+func SubscribeOrderEvents(uid uint) (<-chan OrderEvent, func()) {
+	ch := make(chan OrderEvent, 16)
+	hub.mu.Lock()
+	if hub.subs[uid] == nil {
+		hub.subs[uid] = make(map[chan OrderEvent]struct{})
+	}
+	hub.subs[uid][ch] = struct{}{}
+	hub.mu.Unlock()
+
+	return ch, func() {
+		hub.mu.Lock()
+		defer hub.mu.Unlock()
+		hub.remove(uid, ch)
+	}
+}
+
+func (h *eventHub) dispatch(e OrderEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[e.UserID] {
+		select {
+		case ch <- e:
+		default:
+			logger.Log.Warn("订单事件推送过慢，断开连接", zap.Uint("uid", e.UserID))
+			h.remove(e.UserID, ch)
+		}
+	}
+}
+
+// remove 删除订阅并关闭通道，调用方需持有锁
+func (h *eventHub) remove(uid uint, ch chan OrderEvent) {
+	if _, ok := h.subs[uid][ch]; !ok {
+		return
+	}
+	delete(h.subs[uid], ch)
+	if len(h.subs[uid]) == 0 {
+		delete(h.subs, uid)
+	}
+	close(ch)
+}
+
+// OrderEventsSince 读取 lastID 之后的事件，lastID 为 "0" 时返回保留的全部事件
+func OrderEventsSince(uid uint, lastID string) ([]OrderEvent, error) {
+	if _, _, ok := parseEventID(lastID); !ok {
+		return nil, ErrInvalidEventID
+	}
+	msgs, err := redis.Client.XRange(context.Background(), redis.UserEventsKey(uid), "("+lastID, "+").Result()
+	if err != nil {
+		logger.Log.Warn("读取订单事件失败", zap.Uint("uid", uid), zap.Error(err))
+		return nil, errors.New("系统繁忙，请稍后再试")
+	}
+	events := make([]OrderEvent, 0, len(msgs))
+	for _, m := range msgs {
+		data, _ := m.Values["data"].(string)
+		events = append(events, OrderEvent{ID: m.ID, UserID: uid, Data: data})
+	}
+	return events, nil
+}
+
+// EventAfter 事件ID a 是否在 b 之后，用于补发和实时推送之间去重
+func EventAfter(a, b string) bool {
+	ams, aseq, _ := parseEventID(a)
+	bms, bseq, _ := parseEventID(b)
+	return ams > bms || (ams == bms && aseq > bseq)
+}
+
+// parseEventID 解析 Stream ID（毫秒时间戳-序号，序号可省略）
+func parseEventID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, hasSeq := strings.Cut(id, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !hasSeq {
+		return ms, 0, true
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
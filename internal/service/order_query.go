@@ -0,0 +1,286 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 用户订单查询：列表按创建时间倒序游标分页，走 (user_id, created_at) 联合索引
+// 配置了只读副本时从副本读取，副本有复制延迟，刚支付的订单状态可能稍后才更新
+
+var (
+	ErrInvalidCursor      = errors.New("无效的分页游标")
+	ErrInvalidOrderStatus = errors.New("无效的订单状态")
+)
+
+// OrderView 订单信息
+type OrderView struct {
+	OrderNum    string     `json:"order_num"`
+	ProductID   uint       `json:"product_id"`
+	SessionID   uint       `json:"session_id"`
+	Status      string     `json:"status"` // created/unpaid/paid/shipped/completed/cancelled/refunding/refunded
+	PayDeadline time.Time  `json:"pay_deadline"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	Product      *OrderProduct `json:"product"` // 下单时的商品快照
+	SeckillPrice money.Money   `json:"seckill_price"`
+	Quantity     int           `json:"quantity"`
+	TotalAmount  money.Money   `json:"total_amount"`
+
+	RefundedAmount money.Money `json:"refunded_amount"`
+
+	Shipping   *model.AddressSnapshot `json:"shipping"` // 收货信息，下单时没有地址为 null
+	Carrier    string                 `json:"carrier,omitempty"`
+	TrackingNo string                 `json:"tracking_no,omitempty"`
+	ShippedAt  *time.Time             `json:"shipped_at,omitempty"`
+}
+
+// OrderProduct 订单中的商品信息（下单时的快照）
+type OrderProduct struct {
+	ID       uint        `json:"id"`
+	Name     string      `json:"name"`
+	ImageURL string      `json:"image_url"`
+	Price    money.Money `json:"price"`
+}
+
+// OrderStatusLog 订单状态流转记录
+type OrderStatusLog struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderDetail 订单详情
+type OrderDetail struct {
+	*OrderView
+	History []OrderStatusLog `json:"history"` // 按时间先后排列
+}
+
+func newOrderView(o *model.Order) *OrderView {
+	var shipping *model.AddressSnapshot
+	if o.Shipping.Receiver != "" {
+		shipping = &o.Shipping
+	}
+	return &OrderView{
+		OrderNum:    o.OrderNum,
+		ProductID:   o.ProductID,
+		SessionID:   o.SessionID,
+		Status:      o.Status.String(),
+		PayDeadline: o.PayDeadline,
+		PaidAt:      o.PaidAt,
+		CreatedAt:   o.CreatedAt,
+		Product: &OrderProduct{
+			ID:       o.ProductID,
+			Name:     o.ProductName,
+			ImageURL: o.ProductImage,
+			Price:    o.UnitPrice,
+		},
+		SeckillPrice: o.SeckillPrice,
+		Quantity:     o.Quantity,
+		TotalAmount:  o.TotalAmount,
+
+		RefundedAmount: o.RefundedAmount,
+
+		Shipping:   shipping,
+		Carrier:    o.Carrier,
+		TrackingNo: o.TrackingNo,
+		ShippedAt:  o.ShippedAt,
+	}
+}
+
+// ListUserOrders 查询用户的订单，按创建时间倒序
+// cursor 为上一页返回的游标，首页传空；status 为状态名，为空时不过滤
+// 返回的 nextCursor 为空表示没有更多数据
+func ListUserOrders(uid uint, status, cursor string, size int) ([]*OrderView, string, error) {
+	db := database.Reader().Where("user_id = ?", uid)
+	if status != "" {
+		s, ok := model.ParseOrderStatus(status)
+		if !ok {
+			return nil, "", ErrInvalidOrderStatus
+		}
+		db = db.Where("status = ?", s)
+	}
+	if cursor != "" {
+		createdAt, id, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		db = db.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
+
+	// 多查一条判断是否还有下一页
+	var orders []model.Order
+	if err := db.Order("created_at DESC, id DESC").Limit(size + 1).Find(&orders).Error; err != nil {
+		logger.Log.Error("查询订单列表失败", zap.Uint("uid", uid), zap.Error(err))
+		return nil, "", errors.New("系统内部错误，请稍后再试")
+	}
+	next := ""
+	if len(orders) > size {
+		orders = orders[:size]
+		last := orders[size-1]
+		next = encodeOrderCursor(last.CreatedAt, last.ID)
+	}
+	views := make([]*OrderView, 0, len(orders))
+	for i := range orders {
+		views = append(views, newOrderView(&orders[i]))
+	}
+	return views, next, nil
+}
+
+// encodeOrderCursor 游标为最后一条订单的创建时间（微秒）和ID
+func encodeOrderCursor(createdAt time.Time, id uint) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixMicro(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOrderCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var micros int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &micros, &id); err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMicro(micros), id, nil
+}
+
+// GetOrderDetail 查询用户自己的订单详情和状态流转记录
+func GetOrderDetail(uid uint, orderNum string) (*OrderDetail, error) {
+	var order model.Order
+	err := database.Reader().
+		Where("order_num = ? AND user_id = ?", orderNum, uid).
+		First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		logger.Log.Error("查询订单失败", zap.String("order_num", orderNum), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return orderDetail(&order)
+}
+
+// orderDetail 补充订单的状态流转记录
+func orderDetail(order *model.Order) (*OrderDetail, error) {
+	orderNum := order.OrderNum
+	var history []model.OrderStatusHistory
+	if err := database.Reader().Where("order_id = ?", order.ID).Order("id").Find(&history).Error; err != nil {
+		logger.Log.Error("查询订单状态记录失败", zap.String("order_num", orderNum), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	detail := &OrderDetail{
+		OrderView: newOrderView(order),
+		History:   make([]OrderStatusLog, 0, len(history)),
+	}
+	for _, h := range history {
+		detail.History = append(detail.History, OrderStatusLog{
+			From:      h.FromStatus.String(),
+			To:        h.ToStatus.String(),
+			Actor:     h.Actor,
+			Reason:    h.Reason,
+			CreatedAt: h.CreatedAt,
+		})
+	}
+	return detail, nil
+}
+
+// AdminOrderView 后台订单信息，比用户看到的多了下单用户
+type AdminOrderView struct {
+	*OrderView
+	UserID uint `json:"user_id"`
+}
+
+// AdminOrderDetail 后台订单详情
+type AdminOrderDetail struct {
+	*OrderDetail
+	UserID uint `json:"user_id"`
+}
+
+// AdminOrderQuery 后台订单查询条件，为零值的条件不过滤
+type AdminOrderQuery struct {
+	OrderNum  string
+	UserID    uint
+	SessionID uint
+	Status    string
+	Cursor    string
+	Size      int
+}
+
+// AdminListOrders 后台查询订单，按订单ID倒序游标分页
+// 不按用户查询时没有 (user_id, created_at) 索引可用，按主键分页
+func AdminListOrders(q AdminOrderQuery) ([]*AdminOrderView, string, error) {
+	db := database.Reader().Model(&model.Order{})
+	if q.OrderNum != "" {
+		db = db.Where("order_num = ?", q.OrderNum)
+	}
+	if q.UserID != 0 {
+		db = db.Where("user_id = ?", q.UserID)
+	}
+	if q.SessionID != 0 {
+		db = db.Where("session_id = ?", q.SessionID)
+	}
+	if q.Status != "" {
+		s, ok := model.ParseOrderStatus(q.Status)
+		if !ok {
+			return nil, "", ErrInvalidOrderStatus
+		}
+		db = db.Where("status = ?", s)
+	}
+	if q.Cursor != "" {
+		_, id, err := decodeOrderCursor(q.Cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		db = db.Where("id < ?", id)
+	}
+
+	var orders []model.Order
+	if err := db.Order("id DESC").Limit(q.Size + 1).Find(&orders).Error; err != nil {
+		logger.Log.Error("后台查询订单失败", zap.Error(err))
+		return nil, "", errors.New("系统内部错误，请稍后再试")
+	}
+	next := ""
+	if len(orders) > q.Size {
+		orders = orders[:q.Size]
+		last := orders[q.Size-1]
+		next = encodeOrderCursor(last.CreatedAt, last.ID)
+	}
+	views := make([]*AdminOrderView, 0, len(orders))
+	for i := range orders {
+		views = append(views, &AdminOrderView{OrderView: newOrderView(&orders[i]), UserID: orders[i].UserID})
+	}
+	return views, next, nil
+}
+
+// AdminGetOrderDetail 后台查询任意订单的详情和状态流转记录
+func AdminGetOrderDetail(orderNum string) (*AdminOrderDetail, error) {
+	var order model.Order
+	err := database.Reader().Where("order_num = ?", orderNum).First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		logger.Log.Error("查询订单失败", zap.String("order_num", orderNum), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	detail, err := orderDetail(&order)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminOrderDetail{OrderDetail: detail, UserID: order.UserID}, nil
+}
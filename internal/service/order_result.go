@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 抢购结果：秒杀接口只负责扣减 Redis 库存并投递消息，订单由消费者异步创建
+// 抢购时预先生成订单号并记录为排队中，消费者处理后更新为已创建或失败，客户端按场次轮询
+// 结果保存在场次的 result key 中，随场次一起过期；过期后从 MySQL 订单查询
+
+// 抢购结果状态
+const (
+	ResultQueued  = "queued"  // 排队中，订单尚未创建
+	ResultCreated = "created" // 订单已创建
+	ResultFailed  = "failed"  // 下单失败
+
+	ResultCancelled = "cancelled" // 订单已取消（超时未支付或用户取消）
+)
+
+var ErrNoSeckillRecord = errors.New("没有该场次的抢购记录")
+
+// OrderResult 抢购结果
+type OrderResult struct {
+	OrderNum  string `json:"order_num"`
+	Status    string `json:"status"`           // queued/created/failed/cancelled
+	Reason    string `json:"reason,omitempty"` // 失败原因
+	UpdatedAt int64  `json:"updated_at"`       // 更新时间（unix 秒）
+}
+
+func newOrderResult(orderNum, status, reason string) OrderResult {
+	return OrderResult{
+		OrderNum:  orderNum,
+		Status:    status,
+		Reason:    reason,
+		UpdatedAt: time.Now().Unix(),
+	}
+}
+
+// encodeResult 生成写入 Redis 的结果 JSON
+func encodeResult(orderNum, status, reason string) string {
+	data, _ := json.Marshal(newOrderResult(orderNum, status, reason))
+	return string(data)
+}
+
+// setOrderResult 更新抢购结果，失败只记录日志（客户端会退化为查询 MySQL 订单）
+func setOrderResult(uid, sid int64, orderNum, status, reason string) {
+	err := redis.SetResultScript.Run(context.Background(), redis.Client,
+		[]string{redis.SessionResultKey(uint(sid))},
+		uid, encodeResult(orderNum, status, reason),
+	).Err()
+	if err != nil {
+		logger.Log.Warn("更新抢购结果失败",
+			zap.Int64("uid", uid),
+			zap.String("order_num", orderNum),
+			zap.String("status", status),
+			zap.Error(err),
+		)
+	}
+}
+
+// GetSeckillResult 查询用户在场次中最近一次抢购的结果
+func GetSeckillResult(uid, sid uint) (*OrderResult, error) {
+	data, err := redis.Client.HGet(context.Background(),
+		redis.SessionResultKey(sid), strconv.FormatUint(uint64(uid), 10)).Bytes()
+	if err == nil {
+		var r OrderResult
+		if err := json.Unmarshal(data, &r); err == nil {
+			return &r, nil
+		}
+	} else if !errors.Is(err, goredis.Nil) {
+		logger.Log.Warn("读取抢购结果失败", zap.Uint("uid", uid), zap.Uint("sid", sid), zap.Error(err))
+	}
+
+	// Redis 中没有记录（已过期或已清理），查询 MySQL 中最近的订单
+	var order model.Order
+	err = database.DB.Select("order_num", "status", "created_at").
+		Where("user_id = ? AND session_id = ?", uid, sid).
+		Order("id DESC").
+		First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoSeckillRecord
+	}
+	if err != nil {
+		logger.Log.Error("查询抢购结果失败", zap.Uint("uid", uid), zap.Uint("sid", sid), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	status := ResultCreated
+	if order.Status == model.OrderCancelled {
+		status = ResultCancelled
+	}
+	return &OrderResult{
+		OrderNum:  order.OrderNum,
+		Status:    status,
+		UpdatedAt: order.CreatedAt.Unix(),
+	}, nil
+}
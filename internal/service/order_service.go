@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 订单取消：超时未支付自动取消，用户也可以主动取消
+// 先在事务中把订单改为已取消并归还 MySQL 场次库存，提交后再归还 Redis 库存并释放限购名额
+// 只有状态真正由未支付变为已取消的那次调用才会归还库存，重复取消不会多还
+// 用户主动取消时可以配置保留限购名额，防止反复抢购、取消占用库存
+
+var ErrOrderNotFound = errors.New("订单不存在")
+
+// CancelOrderByUser 用户取消自己的未支付订单，重复取消直接返回成功
+// 其他状态的订单返回 model.ErrIllegalTransition
+func CancelOrderByUser(uid uint, orderNum string) error {
+	keepQuota := config.Get().Order.BlockRebuyAfterCancel
+	_, err := cancelOrder(orderNum, userActor(uid), "用户取消", keepQuota, func(o *model.Order) error {
+		if o.UserID != uid {
+			return ErrOrderNotFound
+		}
+		if o.Status == model.OrderCancelled {
+			return nil
+		}
+		return o.Status.CheckTransition(model.OrderCancelled)
+	})
+	if err != nil && !errors.Is(err, ErrOrderNotFound) && !errors.Is(err, model.ErrIllegalTransition) {
+		logger.Log.Error("取消订单失败", zap.Uint("uid", uid), zap.String("order_num", orderNum), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	return err
+}
+
+// cancelOrder 取消未支付订单，返回是否由本次调用取消（订单不是未支付状态时返回 false）
+// keepQuota 为 true 时不释放用户的限购名额，用户不能再次抢购该场次
+// check 在行锁内校验订单（如归属、是否到期），返回错误时不取消
+func cancelOrder(orderNum, actor, reason string, keepQuota bool, check func(*model.Order) error) (bool, error) {
+	var order model.Order
+	cancelled := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", orderNum).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(&order); err != nil {
+				return err
+			}
+		}
+		if order.Status != model.OrderUnpaid {
+			return nil
+		}
+		if err := transitionOrder(tx, &order, model.OrderCancelled, actor, reason, nil); err != nil {
+			return err
+		}
+		err = tx.Model(&model.SeckillSession{}).
+			Where("id = ?", order.SessionID).
+			Update("stock", gorm.Expr("stock + ?", 1)).Error
+		if err != nil {
+			return err
+		}
+		cancelled = true
+		return nil
+	})
+	if err != nil || !cancelled {
+		return false, err
+	}
+
+	// MySQL 已提交，Redis 归还失败时只会少卖，不会超卖
+	releaseRedisStock(&order, reason, keepQuota)
+	logger.Log.Info("订单已取消",
+		zap.String("order_num", orderNum),
+		zap.Uint("uid", order.UserID),
+		zap.Uint("sid", order.SessionID),
+		zap.String("reason", reason),
+	)
+	return true, nil
+}
+
+// releaseRedisStock 归还 Redis 库存、释放用户的限购名额，并把抢购结果更新为已取消
+// 场次 key 已过期或已清理时脚本不做任何操作
+func releaseRedisStock(order *model.Order, reason string, keepQuota bool) {
+	keys := redis.SessionKeys(order.SessionID)
+	keep := 0
+	if keepQuota {
+		keep = 1
+	}
+	err := redis.ReleaseScript.Run(context.Background(), redis.Client,
+		[]string{keys[0], keys[1], keys[3]},
+		order.UserID, 1, encodeResult(order.OrderNum, ResultCancelled, reason), keep,
+	).Err()
+	if err != nil {
+		logger.Log.Error("归还 Redis 库存失败", zap.String("order_num", order.OrderNum), zap.Error(err))
+	}
+}
+
+var ErrNoShippingAddress = errors.New("订单没有收货地址，请先补充收货地址再发货")
+
+// ShipOrder 后台为已支付订单发货，记录物流公司和物流单号
+func ShipOrder(operatorID uint, orderNum, carrier, trackingNo string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", orderNum).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if order.Shipping.Receiver == "" {
+			return ErrNoShippingAddress
+		}
+		return transitionOrder(tx, &order, model.OrderShipped, adminActor(operatorID), "发货 "+carrier+" "+trackingNo,
+			map[string]interface{}{
+				"carrier":     carrier,
+				"tracking_no": trackingNo,
+				"shipped_at":  time.Now(),
+			})
+	})
+	if err != nil && !errors.Is(err, ErrOrderNotFound) && !errors.Is(err, ErrNoShippingAddress) &&
+		!errors.Is(err, model.ErrIllegalTransition) && !errors.Is(err, ErrOrderStatusChanged) {
+		logger.Log.Error("订单发货失败", zap.String("order_num", orderNum), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	if err == nil {
+		logger.Log.Info("订单已发货", zap.String("order_num", orderNum), zap.String("carrier", carrier), zap.String("tracking_no", trackingNo))
+	}
+	return err
+}
+
+var ErrOrderAddressLocked = errors.New("订单已发货或已关闭，不能修改收货地址")
+
+// SetOrderAddress 用户为未发货的订单选择收货地址（下单时没有地址，或需要更换地址）
+func SetOrderAddress(uid uint, orderNum string, addressID uint) error {
+	return setOrderShipping(orderNum, uid, userActor(uid), func(tx *gorm.DB) (uint, model.AddressSnapshot, error) {
+		var a model.Address
+		if err := findAddress(tx, uid, addressID, &a); err != nil {
+			return 0, model.AddressSnapshot{}, err
+		}
+		return a.ID, a.AddressSnapshot, nil
+	})
+}
+
+// AdminSetOrderAddress 后台为未发货的订单填写收货信息（客服联系用户后补录），订单不关联地址簿
+func AdminSetOrderAddress(operatorID uint, orderNum string, shipping model.AddressSnapshot) error {
+	return setOrderShipping(orderNum, 0, adminActor(operatorID), func(tx *gorm.DB) (uint, model.AddressSnapshot, error) {
+		return 0, shipping, nil
+	})
+}
+
+// setOrderShipping 在行锁内更新订单的收货信息，只有待支付和已支付（未发货）的订单可以修改
+// uid 不为 0 时校验订单归属；resolve 返回地址ID和收货信息快照
+func setOrderShipping(orderNum string, uid uint, actor string,
+	resolve func(tx *gorm.DB) (uint, model.AddressSnapshot, error)) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", orderNum).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && uid != 0 && order.UserID != uid) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch order.Status {
+		case model.OrderCreated, model.OrderUnpaid, model.OrderPaid:
+		default:
+			return ErrOrderAddressLocked
+		}
+		addressID, s, err := resolve(tx)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&order).Updates(map[string]interface{}{
+			"address_id":    addressID,
+			"ship_receiver": s.Receiver,
+			"ship_phone":    s.Phone,
+			"ship_province": s.Province,
+			"ship_city":     s.City,
+			"ship_district": s.District,
+			"ship_detail":   s.Detail,
+		}).Error
+	})
+	if err != nil && !errors.Is(err, ErrOrderNotFound) && !errors.Is(err, ErrOrderAddressLocked) &&
+		!errors.Is(err, ErrAddressNotFound) {
+		logger.Log.Error("修改订单收货地址失败", zap.String("order_num", orderNum), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	if err == nil {
+		logger.Log.Info("订单收货地址已修改", zap.String("order_num", orderNum), zap.String("actor", actor))
+	}
+	return err
+}
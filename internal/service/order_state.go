@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"seckill/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 订单状态机：订单状态只能通过 transitionOrder 修改
+// 流转前按 model 中定义的合法流转校验，更新时带上原状态作为条件，并在同一事务中写入流转记录
+
+// ErrOrderStatusChanged 更新时订单状态已被其他请求修改
+var ErrOrderStatusChanged = errors.New("订单状态已变化，请刷新后重试")
+
+// 操作方
+const actorSystem = "system"
+
+func userActor(uid uint) string {
+	return fmt.Sprintf("user:%d", uid)
+}
+
+func adminActor(uid uint) string {
+	return fmt.Sprintf("admin:%d", uid)
+}
+
+func paymentActor(provider string) string {
+	return "payment:" + provider
+}
+
+// transitionOrder 在事务中流转订单状态并记录流转历史
+// order 应当已在事务中加行锁读取；fields 为同时更新的其他字段
+func transitionOrder(tx *gorm.DB, order *model.Order, to model.OrderStatus, actor, reason string, fields map[string]interface{}) error {
+	if err := order.Status.CheckTransition(to); err != nil {
+		return err
+	}
+	return applyTransition(tx, order, to, actor, reason, fields)
+}
+
+// finishRefundTransition 退款结束时流转订单状态：变为已退款，或回到发起退款前的状态 resume
+func finishRefundTransition(tx *gorm.DB, order *model.Order, resume, to model.OrderStatus, actor, reason string, fields map[string]interface{}) error {
+	if err := order.Status.CheckRefundExit(resume, to); err != nil {
+		return err
+	}
+	return applyTransition(tx, order, to, actor, reason, fields)
+}
+
+// applyTransition 按原状态条件更新订单并写入流转记录，调用方已校验流转合法
+func applyTransition(tx *gorm.DB, order *model.Order, to model.OrderStatus, actor, reason string, fields map[string]interface{}) error {
+	from := order.Status
+	updates := map[string]interface{}{"status": to}
+	for k, v := range fields {
+		updates[k] = v
+	}
+	result := tx.Model(&model.Order{}).
+		Where("id = ? AND status = ?", order.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+	if err := recordOrderStatus(tx, order.ID, from, to, actor, reason); err != nil {
+		return err
+	}
+	order.Status = to
+	return nil
+}
+
+// recordOrderStatus 写入一条状态流转记录
+func recordOrderStatus(tx *gorm.DB, orderID uint, from, to model.OrderStatus, actor, reason string) error {
+	return tx.Create(&model.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}).Error
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/rabbitmq"
+
+	"go.uber.org/zap"
+)
+
+// 支付超时：订单创建后投递延迟消息（TTL + 死信队列），到期仍未支付则取消订单并归还库存
+// 延迟消息发送失败或丢失时，由定时扫描兜底取消已超时的订单
+
+// orderTimeoutLockKey 超时订单扫描锁
+const orderTimeoutLockKey = "seckill:lock:order_timeout"
+
+// sweepGrace 截止时间过后多久由扫描任务处理，正常情况下延迟消息先到
+const sweepGrace = time.Minute
+
+// errPayNotDue 消息提前到达（时钟误差），订单还未到支付截止时间
+var errPayNotDue = errors.New("未到支付截止时间")
+
+// scheduleOrderTimeout 投递支付超时消息，失败由扫描任务兜底
+func scheduleOrderTimeout(orderNum string) {
+	if err := rabbitmq.SendOrderTimeoutMessage(orderNum, config.Get().Order.PayTimeout); err != nil {
+		logger.Log.Warn("投递订单超时消息失败", zap.String("order_num", orderNum), zap.Error(err))
+	}
+}
+
+// cancelExpiredOrder 取消超时未支付的订单，未到截止时间时按剩余时长重新投递延迟消息
+func cancelExpiredOrder(orderNum string) error {
+	var remaining time.Duration
+	_, err := cancelOrder(orderNum, actorSystem, "支付超时", false, func(o *model.Order) error {
+		if remaining = time.Until(o.PayDeadline); remaining > 0 && o.Status == model.OrderUnpaid {
+			return errPayNotDue
+		}
+		return nil
+	})
+	if errors.Is(err, errPayNotDue) {
+		return rabbitmq.RetryOrderTimeoutMessage(orderNum, remaining)
+	}
+	return err
+}
+
+// StartOrderTimeoutConsumer 启动超时队列消费者和兜底扫描
+func StartOrderTimeoutConsumer() {
+	msgs, err := rabbitmq.Channel.Consume(
+		rabbitmq.TimeoutQueueName, //队列名称
+		"",                        //消费者名称
+		false,                     //autoAck 是否自动确认
+		false,                     //exclusive 是否排他
+		false,                     //noLocal 是否本地
+		false,                     //noWait 是否阻塞
+		nil,                       //args其他参数
+	)
+	if err != nil {
+		logger.Log.Fatal("[Worker]订单超时消费者启动失败", zap.Error(err))
+	}
+	go func() {
+		for d := range msgs {
+			var msg rabbitmq.OrderTimeoutMessage
+			if err := json.Unmarshal(d.Body, &msg); err != nil || msg.OrderNum == "" {
+				logger.Log.Warn("订单超时消息格式错误", zap.ByteString("body", d.Body))
+				d.Ack(false)
+				continue
+			}
+			err := cancelExpiredOrder(msg.OrderNum)
+			switch {
+			case err == nil, errors.Is(err, ErrOrderNotFound):
+				d.Ack(false)
+			default:
+				//数据库异常，稍后重新投递
+				logger.Log.Error("取消超时订单失败", zap.String("order_num", msg.OrderNum), zap.Error(err))
+				time.Sleep(time.Second)
+				d.Nack(false, true)
+			}
+		}
+	}()
+
+	startScheduledJob(scheduledJob{
+		name:    "超时订单扫描",
+		lockKey: orderTimeoutLockKey,
+		settings: func() (bool, time.Duration) {
+			return true, config.Get().Order.SweepInterval
+		},
+		run: sweepExpiredOrders,
+	})
+}
+
+// sweepExpiredOrders 取消已超过支付截止时间的未支付订单
+func sweepExpiredOrders(ctx context.Context) {
+	var orderNums []string
+	err := database.DB.Model(&model.Order{}).
+		Where("status = ? AND pay_deadline < ?", model.OrderUnpaid, time.Now().Add(-sweepGrace)).
+		Order("pay_deadline").
+		Limit(500).
+		Pluck("order_num", &orderNums).Error
+	if err != nil {
+		logger.Log.Error("扫描超时订单失败", zap.Error(err))
+		return
+	}
+	cancelled := 0
+	for _, orderNum := range orderNums {
+		if err := cancelExpiredOrder(orderNum); err != nil {
+			logger.Log.Error("取消超时订单失败", zap.String("order_num", orderNum), zap.Error(err))
+			continue
+		}
+		cancelled++
+	}
+	if len(orderNums) > 0 {
+		logger.Log.Info("超时订单扫描完成", zap.Int("found", len(orderNums)), zap.Int("cancelled", cancelled))
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+	"seckill/pkg/payment"
+	"seckill/pkg/snowflake"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 订单支付：用户对未支付订单发起支付，创建支付单后由渠道返回支付链接
+// 渠道异步回调支付结果，验签通过后在事务中把订单由未支付改为已支付
+// 回调可能重复、乱序或迟到：支付单不是待支付状态时直接忽略；订单已取消或已支付时支付单记为待退款
+
+var (
+	ErrOrderPaid       = errors.New("订单已支付")
+	ErrOrderClosed     = errors.New("订单已取消或已超时，无法支付")
+	ErrPaymentNotFound = errors.New("支付单不存在")
+	ErrAmountMismatch  = errors.New("支付金额与支付单不一致")
+)
+
+// PaymentView 返回给客户端的支付信息
+type PaymentView struct {
+	PaymentNo string      `json:"payment_no"`
+	OrderNum  string      `json:"order_num"`
+	Provider  string      `json:"provider"`
+	Amount    money.Money `json:"amount"`
+	PayURL    string      `json:"pay_url"`
+	ExpireAt  int64       `json:"expire_at"` // 支付截止时间（unix 秒）
+}
+
+// PayOrder 为订单创建支付单，同一渠道已有待支付的支付单时直接复用
+func PayOrder(uid uint, orderNum, providerName string) (*PaymentView, error) {
+	if providerName == "" {
+		providerName = config.Get().Payment.DefaultProvider
+	}
+	provider, err := payment.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var order model.Order
+	var p model.Payment
+	reused := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁住订单，避免与超时取消并发
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", orderNum).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.UserID != uid) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		switch {
+		case order.Status == model.OrderPaid:
+			return ErrOrderPaid
+		case !order.Status.CanTransitionTo(model.OrderPaid), !time.Now().Before(order.PayDeadline):
+			return ErrOrderClosed
+		}
+
+		err = tx.Where("order_num = ? AND provider = ? AND status = ? AND pay_url <> ''",
+			orderNum, providerName, model.PaymentPending).
+			Order("id DESC").
+			First(&p).Error
+		if err == nil {
+			reused = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		p = model.Payment{
+			PaymentNo: snowflake.GenerateID(),
+			OrderNum:  orderNum,
+			UserID:    uid,
+			Provider:  providerName,
+			Amount:    order.TotalAmount,
+			Status:    model.PaymentPending,
+		}
+		return tx.Create(&p).Error
+	})
+	if err != nil {
+		if isPayBusinessError(err) {
+			return nil, err
+		}
+		logger.Log.Error("创建支付单失败", zap.String("order_num", orderNum), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+
+	if !reused {
+		// 调用渠道放在事务之外，避免网络请求期间持有订单行锁
+		intent, err := provider.Create(context.Background(), payment.Request{
+			PaymentNo: p.PaymentNo,
+			OrderNum:  orderNum,
+			Amount:    p.Amount,
+			Subject:   order.ProductName,
+			ExpireAt:  order.PayDeadline,
+			NotifyURL: config.Get().Payment.NotifyURL + "/" + providerName,
+		})
+		if err != nil {
+			logger.Log.Error("渠道创建支付失败", zap.String("payment_no", p.PaymentNo), zap.String("provider", providerName), zap.Error(err))
+			database.DB.Model(&p).Update("status", model.PaymentFailed)
+			return nil, errors.New("创建支付失败，请稍后再试")
+		}
+		p.TradeNo, p.PayURL = intent.TradeNo, intent.PayURL
+		if err := database.DB.Model(&p).Updates(map[string]interface{}{
+			"trade_no": p.TradeNo,
+			"pay_url":  p.PayURL,
+		}).Error; err != nil {
+			logger.Log.Error("保存支付链接失败", zap.String("payment_no", p.PaymentNo), zap.Error(err))
+			return nil, errors.New("系统内部错误，请稍后再试")
+		}
+	}
+
+	return &PaymentView{
+		PaymentNo: p.PaymentNo,
+		OrderNum:  orderNum,
+		Provider:  providerName,
+		Amount:    p.Amount,
+		PayURL:    p.PayURL,
+		ExpireAt:  order.PayDeadline.Unix(),
+	}, nil
+}
+
+// isPayBusinessError 是否为可以直接返回给用户的业务错误
+func isPayBusinessError(err error) bool {
+	return errors.Is(err, ErrOrderNotFound) ||
+		errors.Is(err, ErrOrderPaid) ||
+		errors.Is(err, ErrOrderClosed)
+}
+
+// HandlePaymentCallback 处理渠道的支付结果回调，可重复调用
+// 返回 nil 表示已处理（包括重复回调），渠道不再重试
+func HandlePaymentCallback(providerName string, header http.Header, body []byte) error {
+	provider, err := payment.Get(providerName)
+	if err != nil {
+		return err
+	}
+	cb, err := provider.VerifyCallback(header, body)
+	if err != nil {
+		logger.Log.Warn("支付回调验签失败", zap.String("provider", providerName), zap.Error(err))
+		return err
+	}
+	paidAt := cb.PaidAt
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	var p model.Payment
+	var orderStatus model.OrderStatus
+	duplicate := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payment_no = ? AND provider = ?", cb.PaymentNo, providerName).
+			First(&p).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if p.Status != model.PaymentPending {
+			duplicate = true // 重复回调
+			return nil
+		}
+		if !cb.Success {
+			return tx.Model(&p).Updates(map[string]interface{}{
+				"status":   model.PaymentFailed,
+				"trade_no": cb.TradeNo,
+			}).Error
+		}
+		if cb.Amount != p.Amount {
+			return ErrAmountMismatch
+		}
+
+		var order model.Order
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status").
+			Where("order_num = ?", p.OrderNum).
+			First(&order).Error
+		if err != nil {
+			return err
+		}
+		orderStatus = order.Status
+
+		// 订单已取消（超时后才支付）或已由其他支付单支付，钱已经收到，记为待退款
+		status := model.PaymentRefundPending
+		if order.Status == model.OrderUnpaid {
+			status = model.PaymentSucceeded
+			err := transitionOrder(tx, &order, model.OrderPaid, paymentActor(providerName), "支付成功",
+				map[string]interface{}{"paid_at": paidAt})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&p).Updates(map[string]interface{}{
+			"status":   status,
+			"trade_no": cb.TradeNo,
+			"paid_at":  paidAt,
+		}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrPaymentNotFound) || errors.Is(err, ErrAmountMismatch) {
+			logger.Log.Error("支付回调异常", zap.String("payment_no", cb.PaymentNo), zap.Stringer("amount", cb.Amount), zap.Error(err))
+			return err
+		}
+		logger.Log.Error("处理支付回调失败", zap.String("payment_no", cb.PaymentNo), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+
+	switch {
+	case duplicate:
+		logger.Log.Info("重复的支付回调", zap.String("payment_no", p.PaymentNo), zap.Int("status", p.Status))
+	case !cb.Success:
+		logger.Log.Info("支付失败", zap.String("payment_no", p.PaymentNo), zap.String("order_num", p.OrderNum))
+	case orderStatus == model.OrderUnpaid:
+		logger.Log.Info("订单支付成功", zap.String("payment_no", p.PaymentNo), zap.String("order_num", p.OrderNum))
+	default:
+		logger.Log.Warn("订单已关闭后收到支付成功回调，支付单待退款",
+			zap.String("payment_no", p.PaymentNo),
+			zap.String("order_num", p.OrderNum),
+			zap.Stringer("order_status", orderStatus),
+		)
+	}
+	return nil
+}
+
+// CompleteMockPayment 模拟用户在渠道完成支付：按渠道的方式签名后走正常的回调处理
+func CompleteMockPayment(paymentNo string) error {
+	provider, err := payment.Get("mock")
+	if err != nil {
+		return err
+	}
+	mock, ok := provider.(*payment.MockProvider)
+	if !ok {
+		return payment.ErrProviderNotFound
+	}
+	var p model.Payment
+	err = database.DB.Select("payment_no", "amount").
+		Where("payment_no = ? AND provider = ?", paymentNo, mock.Name()).
+		First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPaymentNotFound
+	}
+	if err != nil {
+		logger.Log.Error("查询支付单失败", zap.String("payment_no", paymentNo), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	header, body := mock.SignedCallback(p.PaymentNo, p.Amount)
+	return HandlePaymentCallback(mock.Name(), header, body)
+}
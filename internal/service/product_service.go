@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 后台商品管理：只维护商品基础信息，秒杀价、库存和时间在场次中管理（见 activity_service.go）
+
+var (
+	ErrProductNotFound = errors.New("商品不存在")
+	ErrProductInUse    = errors.New("商品存在未结束的秒杀场次，不能删除")
+	ErrInvalidProduct  = errors.New("商品参数错误")
+)
+
+// ProductInput 创建/修改商品的参数，修改时为空的字段保持不变
+type ProductInput struct {
+	Name        *string      `json:"name"`
+	Price       *money.Money `json:"price"`
+	Description *string      `json:"description"`
+	ImageURL    *string      `json:"image_url"`
+	Images      []string     `json:"images"`
+}
+
+// apply 把参数写入商品
+func (in *ProductInput) apply(p *model.Product) {
+	if in.Name != nil {
+		p.Name = *in.Name
+	}
+	if in.Price != nil {
+		p.Price = *in.Price
+	}
+	if in.Description != nil {
+		p.Description = *in.Description
+	}
+	if in.ImageURL != nil {
+		p.ImageURL = *in.ImageURL
+	}
+	if in.Images != nil {
+		p.Images = in.Images
+	}
+}
+
+// ProductView 商品信息
+type ProductView struct {
+	ID          uint        `json:"id"`
+	Name        string      `json:"name"`
+	Price       money.Money `json:"price"`
+	Description string      `json:"description"`
+	ImageURL    string      `json:"image_url"`
+	Images      []string    `json:"images"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+func newProductView(p *model.Product) *ProductView {
+	images := p.Images
+	if images == nil {
+		images = []string{}
+	}
+	return &ProductView{
+		ID:          p.ID,
+		Name:        p.Name,
+		Price:       p.Price,
+		Description: p.Description,
+		ImageURL:    p.ImageURL,
+		Images:      images,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// validateProduct 校验商品字段
+func validateProduct(p *model.Product) error {
+	switch {
+	case p.Name == "":
+		return fmt.Errorf("%w: 商品名称不能为空", ErrInvalidProduct)
+	case p.Price <= 0:
+		return fmt.Errorf("%w: 价格必须大于 0", ErrInvalidProduct)
+	}
+	return nil
+}
+
+// lockProduct 加行锁读取商品，避免与其他后台操作并发修改
+func lockProduct(tx *gorm.DB, id uint) (*model.Product, error) {
+	var p model.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotFound
+	}
+	return &p, err
+}
+
+// CreateProduct 创建商品
+func CreateProduct(in ProductInput) (*ProductView, error) {
+	var p model.Product
+	in.apply(&p)
+	if err := validateProduct(&p); err != nil {
+		return nil, err
+	}
+	if err := database.DB.Create(&p).Error; err != nil {
+		logger.Log.Error("创建商品失败", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	InvalidateProductCache(p.ID) // 清除该ID可能存在的空值缓存
+	logger.Log.Info("创建商品", zap.Uint("pid", p.ID), zap.String("name", p.Name))
+	return newProductView(&p), nil
+}
+
+// UpdateProduct 修改商品基础信息
+func UpdateProduct(id uint, in ProductInput) (*ProductView, error) {
+	var p *model.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if p, err = lockProduct(tx, id); err != nil {
+			return err
+		}
+		in.apply(p)
+		if err := validateProduct(p); err != nil {
+			return err
+		}
+		return tx.Save(p).Error
+	})
+	if err != nil {
+		return nil, productError("修改商品失败", id, err)
+	}
+	InvalidateProductCache(id)
+	logger.Log.Info("修改商品", zap.Uint("pid", id))
+	return newProductView(p), nil
+}
+
+// DeleteProduct 删除商品（软删除），存在未结束的场次时不能删除
+func DeleteProduct(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		p, err := lockProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = tx.Model(&model.SeckillSession{}).
+			Where("product_id = ? AND end_time > ?", id, time.Now()).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrProductInUse
+		}
+		return tx.Delete(p).Error
+	})
+	if err != nil {
+		return productError("删除商品失败", id, err)
+	}
+	InvalidateProductCache(id)
+	logger.Log.Info("删除商品", zap.Uint("pid", id))
+	return nil
+}
+
+// GetProduct 商品详情
+func GetProduct(id uint) (*ProductView, error) {
+	var p model.Product
+	if err := database.DB.First(&p, id).Error; err != nil {
+		return nil, productError("查询商品失败", id, err)
+	}
+	return newProductView(&p), nil
+}
+
+// ListProducts 分页查询商品
+func ListProducts(keyword string, page, size int) ([]*ProductView, int64, error) {
+	db := database.DB.Model(&model.Product{})
+	if keyword != "" {
+		db = db.Where("name LIKE ?", "%"+keyword+"%")
+	}
+	var total int64
+	var products []model.Product
+	if err := db.Count(&total).Error; err != nil {
+		logger.Log.Error("查询商品失败", zap.Error(err))
+		return nil, 0, errors.New("系统内部错误，请稍后再试")
+	}
+	if err := db.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&products).Error; err != nil {
+		logger.Log.Error("查询商品失败", zap.Error(err))
+		return nil, 0, errors.New("系统内部错误，请稍后再试")
+	}
+	views := make([]*ProductView, 0, len(products))
+	for i := range products {
+		views = append(views, newProductView(&products[i]))
+	}
+	return views, total, nil
+}
+
+// productError 业务错误原样返回，其余记录日志后返回通用错误
+func productError(msg string, id uint, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrProductNotFound
+	}
+	if errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrProductInUse) || errors.Is(err, ErrInvalidProduct) {
+		return err
+	}
+	logger.Log.Error(msg, zap.Uint("pid", id), zap.Error(err))
+	return errors.New("系统内部错误，请稍后再试")
+}
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/money"
+	"seckill/pkg/payment"
+	"seckill/pkg/redis"
+	"seckill/pkg/snowflake"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 退款：用户或后台对已支付订单发起全额或部分退款，订单进入退款中，提交渠道后等待结果
+// 退款完成后累计已退金额，全部退完订单变为已退款，否则回到发起退款前的状态；退款失败同样回到原状态
+// 渠道可能同步返回结果，也可能通过退款回调通知，两者都走 finishRefund，只有第一次生效
+// 订单关闭后才到账的支付单（待退款）由后台单独发起退款，不改变订单状态
+// 提交渠道超时等结果未知时退款单保持退款中，由补偿任务按退款单号重新提交，只有渠道明确拒绝或回调失败才关闭
+
+// refundRetryAfter 退款单提交后多久仍未被渠道受理（没有渠道退款单号）时由补偿任务重新提交
+const refundRetryAfter = time.Minute
+
+// refundRetryLockKey 退款补偿任务锁
+const refundRetryLockKey = "seckill:lock:refund_retry"
+
+var (
+	ErrRefundNotFound       = errors.New("退款单不存在")
+	ErrInvalidRefundAmount  = errors.New("退款金额无效，不能超过可退金额")
+	ErrNoPaidPayment        = errors.New("订单没有可退款的支付记录")
+	ErrPaymentNotRefundable = errors.New("支付单不是待退款状态")
+	ErrRefundInProgress     = errors.New("已有退款正在处理")
+)
+
+var refundStatusNames = map[int]string{
+	model.RefundPending:   "pending",
+	model.RefundSucceeded: "succeeded",
+	model.RefundFailed:    "failed",
+}
+
+// RefundView 退款单信息
+type RefundView struct {
+	RefundNo   string      `json:"refund_no"`
+	OrderNum   string      `json:"order_num"`
+	PaymentNo  string      `json:"payment_no"`
+	Amount     money.Money `json:"amount"`
+	Reason     string      `json:"reason"`
+	Status     string      `json:"status"` // pending/succeeded/failed
+	CreatedAt  time.Time   `json:"created_at"`
+	RefundedAt *time.Time  `json:"refunded_at,omitempty"`
+}
+
+func newRefundView(r *model.Refund) *RefundView {
+	return &RefundView{
+		RefundNo:   r.RefundNo,
+		OrderNum:   r.OrderNum,
+		PaymentNo:  r.PaymentNo,
+		Amount:     r.Amount,
+		Reason:     r.Reason,
+		Status:     refundStatusNames[r.Status],
+		CreatedAt:  r.CreatedAt,
+		RefundedAt: r.RefundedAt,
+	}
+}
+
+// RequestRefund 用户为自己的订单申请退款，amount 为 0 时退还全部可退金额
+func RequestRefund(uid uint, orderNum string, amount money.Money, reason string) (*RefundView, error) {
+	return requestOrderRefund(userActor(uid), uid, orderNum, amount, reason)
+}
+
+// AdminRefund 后台为订单发起退款，amount 为 0 时退还全部可退金额
+func AdminRefund(operatorID uint, orderNum string, amount money.Money, reason string) (*RefundView, error) {
+	return requestOrderRefund(adminActor(operatorID), 0, orderNum, amount, reason)
+}
+
+// requestOrderRefund 创建订单退款单并提交渠道，uid 不为 0 时校验订单归属
+func requestOrderRefund(actor string, uid uint, orderNum string, amount money.Money, reason string) (*RefundView, error) {
+	var refund model.Refund
+	var p model.Payment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var order model.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", orderNum).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && uid != 0 && order.UserID != uid) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// 退款中的订单不能再次发起，同一时间只有一笔订单退款
+		if err := order.Status.CheckTransition(model.OrderRefunding); err != nil {
+			return err
+		}
+		refundable := order.TotalAmount - order.RefundedAmount
+		if amount == 0 {
+			amount = refundable
+		}
+		if amount <= 0 || amount > refundable {
+			return ErrInvalidRefundAmount
+		}
+		err = tx.Where("order_num = ? AND status = ?", orderNum, model.PaymentSucceeded).First(&p).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoPaidPayment
+		}
+		if err != nil {
+			return err
+		}
+
+		from := order.Status
+		if err := transitionOrder(tx, &order, model.OrderRefunding, actor, reason, nil); err != nil {
+			return err
+		}
+		refund = model.Refund{
+			RefundNo:   snowflake.GenerateID(),
+			OrderNum:   orderNum,
+			PaymentNo:  p.PaymentNo,
+			UserID:     order.UserID,
+			Provider:   p.Provider,
+			Amount:     amount,
+			Reason:     reason,
+			Actor:      actor,
+			Status:     model.RefundPending,
+			FromStatus: &from,
+		}
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		if isRefundBusinessError(err) {
+			return nil, err
+		}
+		logger.Log.Error("创建退款单失败", zap.String("order_num", orderNum), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return submitRefund(&refund, &p, actor)
+}
+
+// RefundPayment 后台退还订单关闭后才到账的支付单（待退款状态），全额退款
+func RefundPayment(operatorID uint, paymentNo, reason string) (*RefundView, error) {
+	actor := adminActor(operatorID)
+	var refund model.Refund
+	var p model.Payment
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("payment_no = ?", paymentNo).
+			First(&p).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPaymentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if p.Status != model.PaymentRefundPending {
+			return ErrPaymentNotRefundable
+		}
+		var pending int64
+		err = tx.Model(&model.Refund{}).
+			Where("payment_no = ? AND status = ?", paymentNo, model.RefundPending).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrRefundInProgress
+		}
+		refund = model.Refund{
+			RefundNo:  snowflake.GenerateID(),
+			OrderNum:  p.OrderNum,
+			PaymentNo: p.PaymentNo,
+			UserID:    p.UserID,
+			Provider:  p.Provider,
+			Amount:    p.Amount,
+			Reason:    reason,
+			Actor:     actor,
+			Status:    model.RefundPending,
+		}
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		if isRefundBusinessError(err) {
+			return nil, err
+		}
+		logger.Log.Error("创建退款单失败", zap.String("payment_no", paymentNo), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return submitRefund(&refund, &p, actor)
+}
+
+// isRefundBusinessError 是否为可以直接返回给调用方的业务错误
+func isRefundBusinessError(err error) bool {
+	return errors.Is(err, ErrOrderNotFound) ||
+		errors.Is(err, model.ErrIllegalTransition) ||
+		errors.Is(err, ErrInvalidRefundAmount) ||
+		errors.Is(err, ErrNoPaidPayment) ||
+		errors.Is(err, ErrPaymentNotFound) ||
+		errors.Is(err, ErrPaymentNotRefundable) ||
+		errors.Is(err, ErrRefundInProgress)
+}
+
+// submitRefund 把退款单提交给渠道，调用放在事务之外
+// 渠道明确拒绝时退款单直接失败，订单回到原状态；超时等结果未知时保持退款中，等待回调或补偿任务重新提交
+func submitRefund(r *model.Refund, p *model.Payment, actor string) (*RefundView, error) {
+	provider, err := payment.Get(r.Provider)
+	if err == nil {
+		var result *payment.RefundResult
+		result, err = provider.Refund(context.Background(), payment.RefundRequest{
+			RefundNo:  r.RefundNo,
+			PaymentNo: p.PaymentNo,
+			TradeNo:   p.TradeNo,
+			Amount:    r.Amount,
+			Total:     p.Amount,
+			Reason:    r.Reason,
+			NotifyURL: config.Get().Payment.RefundNotifyURL + "/" + r.Provider,
+		})
+		if err == nil {
+			if result.Completed {
+				return finishRefund(r.RefundNo, true, result.ProviderRefundNo, actor)
+			}
+			// 异步回调按渠道退款单号匹配，写入失败时退款单仍为退款中，由补偿任务重新提交
+			if err := database.DB.Model(r).Update("provider_refund_no", result.ProviderRefundNo).Error; err != nil {
+				logger.Log.Error("保存渠道退款单号失败",
+					zap.String("refund_no", r.RefundNo), zap.String("provider_refund_no", result.ProviderRefundNo), zap.Error(err))
+			}
+			return newRefundView(r), nil
+		}
+	}
+
+	if !errors.Is(err, payment.ErrProviderNotFound) && !errors.Is(err, payment.ErrRefundRejected) {
+		// 渠道可能已经受理，关闭退款单会让订单回到原状态，之后的成功回调被当作重复回调丢弃
+		logger.Log.Warn("渠道受理退款结果未知，等待回调或重新提交",
+			zap.String("refund_no", r.RefundNo), zap.String("provider", r.Provider), zap.Error(err))
+		return newRefundView(r), nil
+	}
+	logger.Log.Error("渠道拒绝退款", zap.String("refund_no", r.RefundNo), zap.String("provider", r.Provider), zap.Error(err))
+	if _, err := finishRefund(r.RefundNo, false, "", actor); err != nil {
+		logger.Log.Error("关闭退款单失败", zap.String("refund_no", r.RefundNo), zap.Error(err))
+	}
+	return nil, errors.New("退款提交失败，请稍后再试")
+}
+
+// StartRefundRetryScheduler 启动退款补偿任务
+func StartRefundRetryScheduler() {
+	startScheduledJob(scheduledJob{
+		name:    "退款补偿",
+		lockKey: refundRetryLockKey,
+		settings: func() (bool, time.Duration) {
+			return true, refundRetryAfter
+		},
+		run: retryPendingRefunds,
+	})
+}
+
+// retryPendingRefunds 重新提交渠道尚未受理的退款单，渠道按退款单号幂等，已受理的不会重复退款
+func retryPendingRefunds(ctx context.Context) {
+	var refunds []model.Refund
+	err := database.DB.
+		Where("status = ? AND (provider_refund_no = '' OR provider_refund_no IS NULL) AND created_at < ?", model.RefundPending, time.Now().Add(-refundRetryAfter)).
+		Order("id").
+		Limit(100).
+		Find(&refunds).Error
+	if err != nil {
+		logger.Log.Error("扫描待提交退款单失败", zap.Error(err))
+		return
+	}
+	for i := range refunds {
+		r := &refunds[i]
+		var p model.Payment
+		if err := database.DB.Where("payment_no = ?", r.PaymentNo).First(&p).Error; err != nil {
+			logger.Log.Error("查询退款支付单失败", zap.String("refund_no", r.RefundNo), zap.Error(err))
+			continue
+		}
+		if _, err := submitRefund(r, &p, actorSystem); err != nil {
+			logger.Log.Error("重新提交退款失败", zap.String("refund_no", r.RefundNo), zap.Error(err))
+		}
+	}
+}
+
+// HandleRefundCallback 处理渠道的退款结果回调，可重复调用
+func HandleRefundCallback(providerName string, header http.Header, body []byte) error {
+	provider, err := payment.Get(providerName)
+	if err != nil {
+		return err
+	}
+	cb, err := provider.VerifyRefundCallback(header, body)
+	if err != nil {
+		logger.Log.Warn("退款回调验签失败", zap.String("provider", providerName), zap.Error(err))
+		return err
+	}
+	var r model.Refund
+	err = database.DB.Select("id", "amount").
+		Where("refund_no = ? AND provider = ?", cb.RefundNo, providerName).
+		First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRefundNotFound
+	}
+	if err != nil {
+		logger.Log.Error("查询退款单失败", zap.String("refund_no", cb.RefundNo), zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	if cb.Success && cb.Amount != r.Amount {
+		logger.Log.Error("退款回调金额不一致", zap.String("refund_no", cb.RefundNo), zap.Stringer("amount", cb.Amount))
+		return ErrAmountMismatch
+	}
+	_, err = finishRefund(cb.RefundNo, cb.Success, cb.ProviderRefundNo, paymentActor(providerName))
+	return err
+}
+
+// finishRefund 记录退款结果，只有退款单处于退款中时生效，重复调用直接返回当前状态
+func finishRefund(refundNo string, success bool, providerRefundNo, actor string) (*RefundView, error) {
+	var r model.Refund
+	var order model.Order
+	restock, duplicate := false, false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refund_no = ?", refundNo).
+			First(&r).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefundNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if r.Status != model.RefundPending {
+			duplicate = true // 重复回调
+			return nil
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": model.RefundFailed}
+		if providerRefundNo != "" {
+			updates["provider_refund_no"] = providerRefundNo
+		}
+		if success {
+			updates["status"] = model.RefundSucceeded
+			updates["refunded_at"] = now
+		}
+		if err := tx.Model(&r).Updates(updates).Error; err != nil {
+			return err
+		}
+		r.Status = updates["status"].(int)
+		if success {
+			r.RefundedAt = &now
+		}
+
+		if r.FromStatus == nil {
+			// 只退支付单：成功后支付单变为已退款，失败时保持待退款，可以重新发起
+			if !success {
+				return nil
+			}
+			return tx.Model(&model.Payment{}).
+				Where("payment_no = ?", r.PaymentNo).
+				Update("status", model.PaymentRefunded).Error
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_num = ?", r.OrderNum).
+			First(&order).Error
+		if err != nil {
+			return err
+		}
+		if !success {
+			return finishRefundTransition(tx, &order, *r.FromStatus, *r.FromStatus, actor, "退款失败", nil)
+		}
+		refunded := order.RefundedAmount + r.Amount
+		to := *r.FromStatus
+		if refunded >= order.TotalAmount {
+			to = model.OrderRefunded
+		}
+		err = finishRefundTransition(tx, &order, *r.FromStatus, to, actor, "退款成功", map[string]interface{}{"refunded_amount": refunded})
+		if err != nil {
+			return err
+		}
+		if to != model.OrderRefunded {
+			return nil
+		}
+		err = tx.Model(&model.Payment{}).
+			Where("payment_no = ?", r.PaymentNo).
+			Update("status", model.PaymentRefunded).Error
+		if err != nil {
+			return err
+		}
+		// 只有未发货的订单放回库存，已发货的商品在用户手里，不能重新售卖
+		if config.Get().Order.RefundRestock && *r.FromStatus == model.OrderPaid {
+			restock, err = restockSession(tx, &order)
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefundNotFound) {
+			return nil, err
+		}
+		logger.Log.Error("更新退款结果失败", zap.String("refund_no", refundNo), zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	if duplicate {
+		return newRefundView(&r), nil
+	}
+
+	if restock {
+		// MySQL 已提交，Redis 归还失败时只会少卖，不会超卖
+		keys := redis.SessionKeys(order.SessionID)
+		err := redis.ReleaseScript.Run(context.Background(), redis.Client,
+			[]string{keys[0], keys[1], keys[3]},
+			order.UserID, order.Quantity, "", 1,
+		).Err()
+		if err != nil {
+			logger.Log.Error("退款归还 Redis 库存失败", zap.String("order_num", order.OrderNum), zap.Error(err))
+		}
+	}
+	logger.Log.Info("退款处理完成",
+		zap.String("refund_no", r.RefundNo),
+		zap.String("order_num", r.OrderNum),
+		zap.Stringer("amount", r.Amount),
+		zap.String("status", refundStatusNames[r.Status]),
+		zap.Bool("restock", restock),
+	)
+	return newRefundView(&r), nil
+}
+
+// restockSession 场次仍在进行中时把全额退款订单的库存放回 MySQL，返回是否需要同步归还 Redis 库存
+// 用户的限购名额不释放
+func restockSession(tx *gorm.DB, order *model.Order) (bool, error) {
+	var session model.SeckillSession
+	err := tx.Select("id", "start_time", "end_time", "archived_at").First(&session, order.SessionID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if session.ArchivedAt != nil || !sessionOnSale(&session, time.Now()) {
+		return false, nil
+	}
+	err = tx.Model(&session).Update("stock", gorm.Expr("stock + ?", order.Quantity)).Error
+	return err == nil, err
+}
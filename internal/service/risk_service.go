@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 风控：在秒杀 Lua 脚本之前拦截黄牛
+// 1. 黑名单：用户ID / IP / 设备ID 命中任一直接拦截
+// 2. 风险评分：新注册账号、请求过于频繁、缺少常规请求头等信号累加，超过阈值拦截
+
+// 黑名单类型
+const (
+	BlacklistUser   = "user"
+	BlacklistIP     = "ip"
+	BlacklistDevice = "device"
+)
+
+// ErrInvalidBlacklistType 不支持的黑名单类型
+var ErrInvalidBlacklistType = errors.New("黑名单类型只能是 user/ip/device")
+
+// ErrRiskRedis 风控读写 Redis 失败，EvaluateRisk 返回的其他错误（如查询 MySQL）不包装此错误
+// 调用方只应把这类错误计入 Redis 熔断器
+var ErrRiskRedis = errors.New("风控 Redis 访问失败")
+
+// BlacklistEntry 黑名单条目
+type BlacklistEntry struct {
+	Type     string     `json:"type"`
+	Value    string     `json:"value"`
+	Reason   string     `json:"reason"`
+	ExpireAt *time.Time `json:"expire_at"` // 为空表示永久
+}
+
+// RiskRequest 参与风控判断的请求信息
+type RiskRequest struct {
+	UserID   int
+	IP       string
+	DeviceID string
+	Headers  map[string]string // 需要检查的请求头及其值
+}
+
+// RiskResult 风控判断结果
+type RiskResult struct {
+	Blocked     bool     // 是否拦截
+	Blacklisted bool     // 是否因黑名单拦截
+	Score       int      // 风险分
+	Reasons     []string // 命中的规则
+}
+
+// blacklistKey 黑名单 key: risk:blacklist:{type}:{value}，value 存拦截原因
+func blacklistKey(typ, value string) string {
+	return fmt.Sprintf("risk:blacklist:%s:%s", typ, value)
+}
+
+func validBlacklistType(typ string) bool {
+	return typ == BlacklistUser || typ == BlacklistIP || typ == BlacklistDevice
+}
+
+// AddBlacklist 添加黑名单，ttl 为 0 表示永久
+func AddBlacklist(typ, value, reason string, ttl time.Duration) error {
+	if !validBlacklistType(typ) {
+		return ErrInvalidBlacklistType
+	}
+	if value == "" {
+		return errors.New("黑名单值不能为空")
+	}
+	if reason == "" {
+		reason = "manual"
+	}
+	if err := redis.Client.Set(context.Background(), blacklistKey(typ, value), reason, ttl).Err(); err != nil {
+		logger.Log.Error("添加黑名单失败", zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	logger.Log.Info("添加黑名单",
+		zap.String("type", typ),
+		zap.String("value", value),
+		zap.String("reason", reason),
+		zap.Duration("ttl", ttl),
+	)
+	return nil
+}
+
+// RemoveBlacklist 移除黑名单
+func RemoveBlacklist(typ, value string) error {
+	if !validBlacklistType(typ) {
+		return ErrInvalidBlacklistType
+	}
+	if err := redis.Client.Del(context.Background(), blacklistKey(typ, value)).Err(); err != nil {
+		logger.Log.Error("移除黑名单失败", zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	logger.Log.Info("移除黑名单", zap.String("type", typ), zap.String("value", value))
+	return nil
+}
+
+// ListBlacklist 列出某类黑名单（管理后台使用，SCAN 遍历不会阻塞 Redis）
+func ListBlacklist(typ string) ([]BlacklistEntry, error) {
+	if !validBlacklistType(typ) {
+		return nil, ErrInvalidBlacklistType
+	}
+	ctx := context.Background()
+	prefix := blacklistKey(typ, "")
+
+	entries := []BlacklistEntry{}
+	iter := redis.Client.Scan(ctx, 0, prefix+"*", 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		pipe := redis.Client.Pipeline()
+		reasonCmd := pipe.Get(ctx, key)
+		ttlCmd := pipe.TTL(ctx, key)
+		if _, err := pipe.Exec(ctx); err != nil {
+			// 遍历过程中刚好过期，跳过
+			continue
+		}
+		entry := BlacklistEntry{
+			Type:   typ,
+			Value:  strings.TrimPrefix(key, prefix),
+			Reason: reasonCmd.Val(),
+		}
+		if ttl := ttlCmd.Val(); ttl > 0 {
+			expireAt := time.Now().Add(ttl)
+			entry.ExpireAt = &expireAt
+		}
+		entries = append(entries, entry)
+	}
+	if err := iter.Err(); err != nil {
+		logger.Log.Error("查询黑名单失败", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return entries, nil
+}
+
+// EvaluateRisk 对秒杀请求做风控判断
+func EvaluateRisk(req RiskRequest) (*RiskResult, error) {
+	cfg := config.Get().Risk
+	ctx := context.Background()
+	result := &RiskResult{}
+
+	// 1. 一次往返完成：黑名单查询 + 请求计数 + 注册时间缓存
+	blacklistKeys := []string{blacklistKey(BlacklistUser, strconv.Itoa(req.UserID))}
+	if req.IP != "" {
+		blacklistKeys = append(blacklistKeys, blacklistKey(BlacklistIP, req.IP))
+	}
+	if req.DeviceID != "" {
+		blacklistKeys = append(blacklistKeys, blacklistKey(BlacklistDevice, req.DeviceID))
+	}
+	freqKey := fmt.Sprintf("risk:freq:%d", req.UserID)
+	ctimeKey := fmt.Sprintf("risk:user:ctime:%d", req.UserID)
+
+	pipe := redis.Client.Pipeline()
+	// 黑名单 key 不在同一个槽，Redis Cluster 下不能用 MGET，逐个 GET
+	blackCmds := make([]*goredis.StringCmd, len(blacklistKeys))
+	for i, key := range blacklistKeys {
+		blackCmds[i] = pipe.Get(ctx, key)
+	}
+	freqCmd := pipe.Incr(ctx, freqKey)
+	pipe.ExpireNX(ctx, freqKey, cfg.FreqWindow)
+	ctimeCmd := pipe.Get(ctx, ctimeKey)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, fmt.Errorf("%w: %v", ErrRiskRedis, err)
+	}
+
+	// 2. 黑名单
+	for i, cmd := range blackCmds {
+		if cmd.Err() == nil {
+			result.Blocked = true
+			result.Blacklisted = true
+			result.Reasons = append(result.Reasons, fmt.Sprintf("blacklist:%s", blacklistKeys[i]))
+		}
+	}
+	if result.Blacklisted {
+		return result, nil
+	}
+
+	// 3. 新注册账号
+	if cfg.NewAccountAge > 0 {
+		createdAt, err := userCreatedAt(ctx, req.UserID, ctimeCmd)
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(createdAt) < cfg.NewAccountAge {
+			result.Score += cfg.NewAccountScore
+			result.Reasons = append(result.Reasons, "new_account")
+		}
+	}
+
+	// 4. 请求频率
+	if cfg.FreqLimit > 0 && freqCmd.Val() > int64(cfg.FreqLimit) {
+		result.Score += cfg.FreqScore
+		result.Reasons = append(result.Reasons, "high_frequency")
+	}
+
+	// 5. 缺少常规请求头（脚本经常不带）
+	for name, val := range req.Headers {
+		if val == "" {
+			result.Score += cfg.MissingHeaderScore
+			result.Reasons = append(result.Reasons, "missing_header:"+name)
+		}
+	}
+
+	result.Blocked = cfg.BlockScore > 0 && result.Score >= cfg.BlockScore
+	return result, nil
+}
+
+// userCreatedAt 获取用户注册时间，优先读 Redis 缓存，未命中时查库并回写
+func userCreatedAt(ctx context.Context, uid int, cached *goredis.StringCmd) (time.Time, error) {
+	if ts, err := cached.Int64(); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	var user model.User
+	if err := database.DB.Select("id", "created_at").First(&user, uid).Error; err != nil {
+		return time.Time{}, err
+	}
+	key := fmt.Sprintf("risk:user:ctime:%d", uid)
+	redis.Client.Set(ctx, key, user.CreatedAt.Unix(), 24*time.Hour)
+	return user.CreatedAt, nil
+}
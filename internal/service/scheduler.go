@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	"go.uber.org/zap"
+)
+
+// 定时任务：多副本部署时每轮先获取分布式锁，同一时间只有一个副本执行
+// 锁的有效期等于执行间隔，持有锁的副本崩溃后下一轮由其他副本接手
+
+// scheduledJob 定时任务
+type scheduledJob struct {
+	name     string                                        // 任务名称，用于日志
+	lockKey  string                                        // 分布式锁 key
+	settings func() (enabled bool, interval time.Duration) // 每轮重新读取配置，开关和间隔支持热更新
+	run      func(ctx context.Context)
+}
+
+// startScheduledJob 启动定时任务，启动时立即执行一次
+func startScheduledJob(job scheduledJob) {
+	go func() {
+		for {
+			enabled, interval := job.settings()
+			if enabled {
+				job.runLocked(interval)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// runLocked 加锁执行一轮
+func (job scheduledJob) runLocked(interval time.Duration) {
+	ctx := context.Background()
+	unlock, ok, err := redis.TryLock(ctx, job.lockKey, interval)
+	if err != nil {
+		logger.Log.Warn("获取任务锁失败", zap.String("job", job.name), zap.Error(err))
+		return
+	}
+	if !ok {
+		return // 其他副本正在执行
+	}
+	defer unlock()
+	job.run(ctx)
+}
@@ -2,58 +2,102 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+
+	"seckill/pkg/breaker"
 	"seckill/pkg/logger"
 	"seckill/pkg/rabbitmq"
 	"seckill/pkg/redis" // 引入 Redis 包
+	"seckill/pkg/snowflake"
 
 	"go.uber.org/zap"
 )
 
-// SeckillV2 使用 Redis Lua 脚本进行原子扣减
-func SeckillV2(userID int, productID int) (bool, string) {
+// SeckillV2 使用 Redis Lua 脚本进行原子扣减，按场次抢购
+// 抢购成功时返回预先生成的订单号，订单由消费者异步创建，客户端通过 GetSeckillResult 轮询结果
+// addressID 为收货地址，调用前已校验归属；为 0 时下单使用默认地址
+func SeckillV2(userID int, sessionID int, addressID uint) (bool, string, string) {
 	ctx := context.Background()
 
 	// 1. 准备 Key
-	// seckill:stock:1 (String 类型，存库存数)
-	stockKey := fmt.Sprintf("seckill:stock:%d", productID)
-	// seckill:bought:1 (Set 类型，存买到的用户ID)
-	boughtKey := fmt.Sprintf("seckill:bought:%d", productID)
-
-	// 2. 执行 Lua 脚本
-	// Keys: [stockKey, boughtKey]
-	// Args: [userID]
-	result, err := redis.SeckillScript.Run(ctx, redis.Client,
-		[]string{stockKey, boughtKey},
-		userID).Int()
+	// seckill:session:{1}:stock  (String 类型，存库存数)
+	// seckill:session:{1}:bought (Hash 类型，存用户已购数量)
+	// seckill:session:{1}:info   (Hash 类型，存开始/结束时间和限购数)
+	// seckill:session:{1}:result (Hash 类型，存用户最近一次抢购的订单号和状态)
+	keys := redis.SessionKeys(uint(sessionID))
+
+	// 2. 熔断检查
+	// MQ 熔断时下单消息发不出去，不能再扣 Redis 库存
+	// 冷却结束后 State 返回半开，请求继续走到下面的 Do，由 Allow 放行探测请求
+	if breaker.Get(breaker.RabbitMQ).State() == breaker.StateOpen {
+		return false, "", "系统繁忙，请稍后再试"
+	}
+	redisBreaker := breaker.Get(breaker.Redis)
+	redisDone, err := redisBreaker.Allow()
+	if err != nil {
+		return false, "", "系统繁忙，请稍后再试"
+	}
+
+	// 3. 布隆过滤器拦截不存在的场次ID，避免无效请求打到 Lua 脚本
+	// 查询失败时放行，由 Lua 脚本判断场次是否存在
+	exists, err := redis.SessionBloom.MightContain(ctx, strconv.Itoa(sessionID))
+	if err == nil && !exists {
+		redisDone(true)
+		return false, "", "秒杀场次不存在"
+	}
+
+	// 4. 执行 Lua 脚本
+	// Keys: [stockKey, boughtKey, infoKey, resultKey]
+	// Args: [userID, 排队中的抢购结果]
+	orderNum := snowflake.GenerateID()
+	queued := encodeResult(orderNum, ResultQueued, "")
+	result, err := redis.SeckillScript.Run(ctx, redis.Client, keys, userID, queued).Int()
+	redisDone(err == nil)
 
 	if err != nil {
 		logger.Log.Error("执行 Lua 脚本失败", zap.Error(err))
-		return false, "系统繁忙，请稍后再试"
+		return false, "", "系统繁忙，请稍后再试"
 	}
 
-	// 3. 处理 Lua 返回值
+	// 5. 处理 Lua 返回值
 	switch result {
 	case -1:
 		// 对应 Lua 里的 return -1
-		logger.Log.Warn("重复购买拦截", zap.Int("uid", userID))
-		return false, "您已经抢购过了，请勿重复下单"
+		logger.Log.Warn("超出限购拦截", zap.Int("uid", userID), zap.Int("sid", sessionID))
+		return false, "", "您已达到本场限购数量"
 	case -2:
 		// 对应 Lua 里的 return -2
-		logger.Log.Warn("库存不足", zap.Int("pid", productID))
-		return false, "手慢了，商品已抢光"
+		logger.Log.Warn("库存不足", zap.Int("sid", sessionID))
+		return false, "", "手慢了，商品已抢光"
+	case -3:
+		return false, "", "秒杀尚未开始"
+	case -4:
+		return false, "", "秒杀已结束"
+	case -5:
+		return false, "", "秒杀场次不存在"
 	case 1:
 		// 对应 Lua 里的 return 1
 		logger.Log.Info("Redis 抢购成功", zap.Int("uid", userID))
 
 		// RabbitMQ 发送逻辑
-		err := rabbitmq.SendSeckillMessage(int64(userID), int64(productID))
+		err := breaker.Get(breaker.RabbitMQ).Do(func() error {
+			return rabbitmq.SendSeckillMessage(int64(userID), int64(sessionID), orderNum, addressID)
+		})
 		if err != nil {
-			return false, "订单创建失败，请稍后再试"
+			logger.Log.Error("发送下单消息失败", zap.String("order_num", orderNum), zap.Error(err))
+			// 消息没有发出去，订单不会创建，归还库存和限购名额
+			releaseErr := redis.ReleaseScript.Run(ctx, redis.Client,
+				[]string{keys[0], keys[1], keys[3]},
+				userID, 1, encodeResult(orderNum, ResultFailed, "订单创建失败"),
+			).Err()
+			if releaseErr != nil {
+				logger.Log.Error("归还库存失败", zap.String("order_num", orderNum), zap.Error(releaseErr))
+			}
+			return false, "", "订单创建失败，请稍后再试"
 		}
 
-		return true, "抢购成功！正在生成订单..."
+		return true, orderNum, "抢购成功！正在生成订单..."
 	}
 
-	return false, "未知错误"
+	return false, "", "未知错误"
 }
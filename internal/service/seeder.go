@@ -2,18 +2,18 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"seckill/internal/model"
 	"seckill/pkg/database"
 	"seckill/pkg/logger"
+	"seckill/pkg/money"
 	"seckill/pkg/redis"
 
 	"go.uber.org/zap"
 )
 
-// InitProductData 负责初始化测试商品
+// InitProductData 负责初始化测试商品和秒杀场次
 func InitProductData() {
 	var count int64
 	if err := database.DB.Model(&model.Product{}).Count(&count).Error; err != nil {
@@ -25,12 +25,20 @@ func InitProductData() {
 		logger.Log.Info("检测到数据库为空，正在初始化测试商品...")
 
 		p := model.Product{
-			Name:         "iPhone 15 Pro",
-			Description:  "双十一特价抢购 iPhone 15 Pro 256G，手慢无！",  // 对应 Description
-			ImageURL:     "http://image.test.com/iphone.jpg", // 对应 ImageURL
-			Price:        8999.00,
-			SeckillPrice: 1.00,
+			Name:        "iPhone 15 Pro",
+			Description: "双十一特价抢购 iPhone 15 Pro 256G，手慢无！",  // 对应 Description
+			ImageURL:    "http://image.test.com/iphone.jpg", // 对应 ImageURL
+			Price:       money.FromYuan(8999),
+		}
+		activity := model.SeckillActivity{
+			Name:        "双十一秒杀",
+			Description: "测试活动",
+		}
+		session := model.SeckillSession{
+			SeckillPrice: money.FromYuan(1),
+			TotalStock:   100,
 			Stock:        100,
+			PerUserLimit: 1,
 			StartTime:    time.Now(),                     // 对应 StartTime (大写)
 			EndTime:      time.Now().Add(24 * time.Hour), // 对应 EndTime (大写)
 		}
@@ -39,19 +47,25 @@ func InitProductData() {
 			logger.Log.Error("初始化商品失败", zap.Error(err))
 			return
 		}
-		logger.Log.Info("mysql数据写入成功", zap.Uint("id", p.ID))
-		//2、库存预热：写入redis
-		//key格式：seckill:stock:商品ID
-		redisKey := fmt.Sprintf("seckill:stock:%d", p.ID)
-		//写入redis，不设置过期时间
-		err := redis.Client.Set(context.Background(), redisKey, p.Stock, 0).Err()
-		if err != nil {
-			logger.Log.Error("初始化商品库存到Redis失败", zap.Error(err))
+		if err := database.DB.Create(&activity).Error; err != nil {
+			logger.Log.Error("初始化活动失败", zap.Error(err))
+			return
+		}
+		session.ActivityID = activity.ID
+		session.ProductID = p.ID
+		if err := database.DB.Create(&session).Error; err != nil {
+			logger.Log.Error("初始化场次失败", zap.Error(err))
+			return
+		}
+		logger.Log.Info("mysql数据写入成功", zap.Uint("pid", p.ID), zap.Uint("sid", session.ID))
+		//2、库存预热：写入redis（库存 + 时间 + 限购）
+		if err := syncSessionToRedis(context.Background(), &session); err != nil {
+			logger.Log.Error("初始化场次库存到Redis失败", zap.Error(err))
 			return
 		}
 		logger.Log.Info("Redis库存预热成功",
-			zap.String("key", redisKey),
-			zap.Int("stock", p.Stock),
+			zap.String("key", redis.SessionStockKey(session.ID)),
+			zap.Int("stock", session.Stock),
 		)
 	}
 }
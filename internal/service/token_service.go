@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+	"seckill/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// 令牌管理
+// access token: 短期 JWT，无状态校验，登出后按 jti 加入黑名单
+// refresh token: 随机串存 Redis，每次刷新都轮换（旧的立即失效）
+//
+// Redis key:
+//   auth:refresh:{token}      -> uid            refresh token
+//   auth:user_refresh:{uid}   -> set(token)     用户的全部 refresh token，用于踢下线
+//   auth:denylist:{jti}       -> 1              已登出的 access token
+//   auth:revoked_at:{uid}     -> unix 秒        该时间之前签发的 access token 全部失效
+
+var (
+	ErrInvalidRefreshToken = errors.New("Refresh Token 无效或已过期")
+	ErrUserDisabled        = errors.New("用户已被禁用")
+)
+
+// TokenPair 登录/刷新返回的令牌对
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // access token 有效秒数
+}
+
+func refreshKey(token string) string {
+	return "auth:refresh:" + token
+}
+
+func userRefreshKey(uid uint) string {
+	return fmt.Sprintf("auth:user_refresh:%d", uid)
+}
+
+func denylistKey(jti string) string {
+	return "auth:denylist:" + jti
+}
+
+func revokedAtKey(uid uint) string {
+	return fmt.Sprintf("auth:revoked_at:%d", uid)
+}
+
+// IssueTokens 为用户签发 access token 和 refresh token
+func IssueTokens(user *model.User) (*TokenPair, error) {
+	cfg := config.Get().JWT
+
+	role := user.Role
+	if role == "" {
+		role = model.RoleUser
+	}
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, role)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := utils.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	pipe := redis.Client.TxPipeline()
+	pipe.Set(ctx, refreshKey(refreshToken), user.ID, cfg.RefreshExpireTime)
+	pipe.SAdd(ctx, userRefreshKey(user.ID), refreshToken)
+	pipe.Expire(ctx, userRefreshKey(user.ID), cfg.RefreshExpireTime)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(cfg.ExpireTime.Seconds()),
+	}, nil
+}
+
+// RefreshTokens 用 refresh token 换取新的令牌对，旧 refresh token 立即作废
+func RefreshTokens(refreshToken string) (*TokenPair, error) {
+	ctx := context.Background()
+
+	// 1. 取出并删除旧 token（GETDEL 保证同一个 token 只能用一次）
+	uidStr, err := redis.Client.GetDel(ctx, refreshKey(refreshToken)).Result()
+	if errors.Is(err, goredis.Nil) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		logger.Log.Error("刷新令牌失败，Redis 错误", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	uid, _ := strconv.ParseUint(uidStr, 10, 64)
+	redis.Client.SRem(ctx, userRefreshKey(uint(uid)), refreshToken)
+
+	// 2. 重新检查用户状态，被禁用的用户不能续期
+	var user model.User
+	if err := database.DB.First(&user, uid).Error; err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.Status != 1 {
+		logger.Log.Warn("刷新令牌失败，用户被禁用", zap.Uint("uid", user.ID))
+		return nil, ErrUserDisabled
+	}
+
+	// 3. 签发新令牌对
+	pair, err := IssueTokens(&user)
+	if err != nil {
+		logger.Log.Error("刷新令牌失败，签发错误", zap.Error(err))
+		return nil, errors.New("系统内部错误，请稍后再试")
+	}
+	return pair, nil
+}
+
+// Logout 登出当前会话：access token 加入黑名单，refresh token 作废
+func Logout(uid uint, claims jwt.MapClaims, refreshToken string) error {
+	ctx := context.Background()
+	pipe := redis.Client.TxPipeline()
+
+	// 1. access token 黑名单只需保留到它本身过期
+	if jti, ok := claims["jti"].(string); ok {
+		exp, _ := claims.GetExpirationTime()
+		if exp != nil {
+			if ttl := time.Until(exp.Time); ttl > 0 {
+				pipe.Set(ctx, denylistKey(jti), 1, ttl)
+			}
+		}
+	}
+
+	// 2. 只能作废属于自己的 refresh token
+	if refreshToken != "" {
+		owner, err := redis.Client.Get(ctx, refreshKey(refreshToken)).Result()
+		if err == nil && owner == strconv.FormatUint(uint64(uid), 10) {
+			pipe.Del(ctx, refreshKey(refreshToken))
+			pipe.SRem(ctx, userRefreshKey(uid), refreshToken)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Log.Error("登出失败", zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+	logger.Log.Info("用户登出", zap.Uint("uid", uid))
+	return nil
+}
+
+// RevokeUserSessions 踢下线：该用户此前签发的所有令牌立即失效
+// 用于“退出所有设备”、禁用用户、修改权限等场景
+func RevokeUserSessions(uid uint) error {
+	cfg := config.Get().JWT
+	ctx := context.Background()
+
+	tokens, err := redis.Client.SMembers(ctx, userRefreshKey(uid)).Result()
+	if err != nil {
+		logger.Log.Error("踢下线失败，Redis 错误", zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+
+	pipe := redis.Client.TxPipeline()
+	// access token 最长存活 ExpireTime，标记保留这么久即可
+	pipe.Set(ctx, revokedAtKey(uid), time.Now().Unix(), cfg.ExpireTime)
+	for _, t := range tokens {
+		pipe.Del(ctx, refreshKey(t))
+	}
+	pipe.Del(ctx, userRefreshKey(uid))
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Log.Error("踢下线失败，Redis 错误", zap.Error(err))
+		return errors.New("系统内部错误，请稍后再试")
+	}
+
+	logger.Log.Info("用户全部会话已注销", zap.Uint("uid", uid), zap.Int("refresh_tokens", len(tokens)))
+	return nil
+}
+
+// IsTokenRevoked 检查 access token 是否已登出或被踢下线
+func IsTokenRevoked(uid uint, claims jwt.MapClaims) (bool, error) {
+	ctx := context.Background()
+	jti, _ := claims["jti"].(string)
+
+	pipe := redis.Client.Pipeline()
+	deniedCmd := pipe.Exists(ctx, denylistKey(jti))
+	revokedCmd := pipe.Get(ctx, revokedAtKey(uid))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return false, err
+	}
+
+	if deniedCmd.Val() > 0 {
+		return true, nil
+	}
+	if revokedAt, err := revokedCmd.Int64(); err == nil {
+		iat, _ := claims.GetIssuedAt()
+		if iat == nil || iat.Unix() < revokedAt {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -57,30 +57,30 @@ func Register(username, password, phone string) error {
 }
 
 // Login用户登录
-func Login(username, password string) (string, error) {
+func Login(username, password string) (*TokenPair, error) {
 	var user model.User
 	//1、根据用户名查询用户信息
 	if err := database.DB.Where("username=?", username).First(&user).Error; err != nil {
-		return "", errors.New("用户不存在")
+		return nil, errors.New("用户不存在")
 	}
 	//2、对比密码是否正确
 	if !utils.CheckPasswordHash(password, user.Password) {
 		logger.Log.Warn("用户登录失败，密码错误", zap.String("username", username))
-		return "", errors.New("账号或密码错误")
+		return nil, errors.New("账号或密码错误")
 	}
 	//3、检查用户状态
 	if user.Status != 1 {
 		logger.Log.Warn("用户登录失败，用户被禁用", zap.String("username", username))
-		return "", errors.New("用户已被禁用")
+		return nil, ErrUserDisabled
 	}
 
-	//4、颁发token
-	token, err := utils.GenerateToken(user.ID, username)
+	//4、颁发 access token + refresh token
+	tokens, err := IssueTokens(&user)
 	if err != nil {
 		logger.Log.Error("用户登录失败，Token生成错误", zap.Error(err))
-		return "", errors.New("系统内部错误，请稍后再试")
+		return nil, errors.New("系统内部错误，请稍后再试")
 	}
 	//成功登录
 	logger.Log.Info("用户登录成功", zap.String("username", username))
-	return token, nil
+	return tokens, nil
 }
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"seckill/internal/model"
+	"seckill/pkg/config"
+	"seckill/pkg/database"
+	"seckill/pkg/logger"
+	"seckill/pkg/redis"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 库存预热：场次开始前把库存、时间、限购和布隆过滤器写入 Redis
+// 后台创建的场次会立即同步，这里兜底处理重启、Redis 数据丢失和直接改库等情况
+// 可以重复执行：库存只在不存在时写入，不会覆盖抢购中已经扣减的库存
+
+// warmupLockKey 多副本部署时只有拿到锁的副本执行预热
+const warmupLockKey = "seckill:lock:warmup"
+
+// WarmUpSessions 预热 lead 时间内开始、且尚未结束的场次，返回预热的场次数
+func WarmUpSessions(ctx context.Context, lead time.Duration) (int, error) {
+	now := time.Now()
+	total := 0
+	var sessions []model.SeckillSession
+	result := database.DB.
+		Select("id", "stock", "per_user_limit", "start_time", "end_time").
+		Where("start_time <= ? AND end_time > ?", now.Add(lead), now).
+		FindInBatches(&sessions, config.Get().Warmup.BatchSize, func(tx *gorm.DB, batch int) error {
+			pipe := redis.Client.Pipeline()
+			for i := range sessions {
+				writeSessionToRedis(ctx, pipe, &sessions[i], false)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			total += len(sessions)
+			return nil
+		})
+	return total, result.Error
+}
+
+// StartWarmupScheduler 启动定时预热
+func StartWarmupScheduler() {
+	startScheduledJob(scheduledJob{
+		name:    "库存预热",
+		lockKey: warmupLockKey,
+		settings: func() (bool, time.Duration) {
+			cfg := config.Get().Warmup
+			return cfg.Enabled, cfg.Interval
+		},
+		run: runWarmup,
+	})
+}
+
+// runWarmup 执行一轮预热
+func runWarmup(ctx context.Context) {
+	start := time.Now()
+	n, err := WarmUpSessions(ctx, config.Get().Warmup.LeadTime)
+	if err != nil {
+		logger.Log.Error("库存预热失败", zap.Int("sessions", n), zap.Error(err))
+		return
+	}
+	if n > 0 {
+		logger.Log.Info("库存预热完成", zap.Int("sessions", n), zap.Duration("cost", time.Since(start)))
+	}
+}
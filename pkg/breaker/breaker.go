@@ -0,0 +1,233 @@
+package breaker
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"seckill/pkg/config"
+	"seckill/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// 熔断器：依赖连续失败达到阈值后直接快速失败，不再等待超时
+// 状态流转: 关闭 -(连续失败)-> 打开 -(冷却结束)-> 半开 -(探测成功)-> 关闭
+//                                                   └-(探测失败)-> 打开
+
+// 被保护的依赖名称，对应配置文件 breakers 下的键
+const (
+	Redis     = "redis"
+	RabbitMQ  = "rabbitmq"
+	MySQL     = "mysql"
+	RateLimit = "ratelimit" // 限流脚本单独熔断，限流故障不影响秒杀脚本使用的 Redis 熔断器
+)
+
+// ErrOpen 熔断器打开时返回，调用方应立即失败
+var ErrOpen = errors.New("熔断器已打开，依赖暂时不可用")
+
+// State 熔断器状态
+type State int
+
+const (
+	StateClosed   State = iota // 关闭：正常放行
+	StateOpen                  // 打开：全部快速失败
+	StateHalfOpen              // 半开：放行少量探测请求
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// 熔断器指标，通过 expvar 暴露
+var (
+	stateVars    = expvar.NewMap("breaker_state")    // 各熔断器当前状态 0关闭 1打开 2半开
+	openedVars   = expvar.NewMap("breaker_opened")   // 各熔断器累计打开次数
+	rejectedVars = expvar.NewMap("breaker_rejected") // 各熔断器累计快速失败次数
+)
+
+// Breaker 单个依赖的熔断器，并发安全
+type Breaker struct {
+	name     string
+	settings config.BreakerConfig
+
+	mu        sync.Mutex
+	state     State
+	failures  int       // 关闭状态下的连续失败次数
+	openedAt  time.Time // 最近一次打开的时间
+	probes    int       // 半开状态已放行的探测请求数
+	successes int       // 半开状态探测成功数
+	gen       uint64    // 状态代数，每次切换状态加一，上报结果时丢弃在其他状态下放行的调用
+}
+
+var (
+	breakers = map[string]*Breaker{}
+	mu       sync.Mutex
+)
+
+// Get 获取依赖对应的熔断器，首次使用时按当前配置创建
+// 每次获取时同步最新配置，热更新后的阈值对下一次调用生效，当前状态和计数保留
+func Get(name string) *Breaker {
+	settings := config.Get().Breakers[name]
+	mu.Lock()
+	b, ok := breakers[name]
+	if !ok {
+		b = New(name, settings)
+		breakers[name] = b
+	}
+	mu.Unlock()
+	if ok {
+		b.update(settings)
+	}
+	return b
+}
+
+// New 创建熔断器，未设置的参数使用默认值
+func New(name string, settings config.BreakerConfig) *Breaker {
+	b := &Breaker{name: name, settings: withDefaults(settings)}
+	stateVars.Add(name, 0)
+	return b
+}
+
+// withDefaults 补齐未设置的参数
+func withDefaults(settings config.BreakerConfig) config.BreakerConfig {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 10 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return settings
+}
+
+// update 替换熔断参数
+func (b *Breaker) update(settings config.BreakerConfig) {
+	settings = withDefaults(settings)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.settings = settings
+}
+
+// Allow 判断是否放行本次调用，放行后必须调用 done 上报结果
+// done 只统计放行时所在状态的结果：关闭状态放行、半开后才返回的慢请求不算探测
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		// 冷却时间已过，进入半开状态开始探测
+		if time.Since(b.openedAt) < b.settings.OpenTimeout {
+			rejectedVars.Add(b.name, 1)
+			return nil, ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= b.settings.HalfOpenRequests {
+			rejectedVars.Add(b.name, 1)
+			return nil, ErrOpen
+		}
+		b.probes++
+	}
+	gen := b.gen
+	return func(success bool) {
+		b.done(gen, success)
+	}, nil
+}
+
+// done 上报调用结果
+// success 只应反映依赖本身是否健康，库存不足等业务失败应按成功上报
+func (b *Breaker) done(gen uint64, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.gen {
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.settings.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// Do 在熔断保护下执行 fn，fn 返回的任何错误都视为依赖失败
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err == nil)
+	return err
+}
+
+// State 当前状态，只读不会切换状态
+// 打开状态冷却结束后返回半开，表示下一次 Allow 会放行探测请求
+// 调用方不能只凭 State 判断放行，否则熔断器打开后没有请求走到 Allow，永远不会恢复
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// setState 切换状态并重置计数，调用方需持有锁
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	b.gen++
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if to == StateOpen {
+		b.openedAt = time.Now()
+		openedVars.Add(b.name, 1)
+	}
+
+	v := new(expvar.Int)
+	v.Set(int64(to))
+	stateVars.Set(b.name, v)
+
+	fields := []zap.Field{
+		zap.String("name", b.name),
+		zap.String("from", from.String()),
+		zap.String("to", to.String()),
+	}
+	if to == StateOpen {
+		logger.Log.Error("熔断器打开", fields...)
+	} else {
+		logger.Log.Warn("熔断器状态变更", fields...)
+	}
+}
@@ -0,0 +1,195 @@
+package breaker
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"seckill/pkg/config"
+	"seckill/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const openTimeout = 20 * time.Millisecond
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func newTestBreaker(t *testing.T, halfOpen int) *Breaker {
+	return New(t.Name(), config.BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      openTimeout,
+		HalfOpenRequests: halfOpen,
+	})
+}
+
+// call 放行一次调用并上报结果
+func call(t *testing.T, b *Breaker, success bool) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() = %v, want nil", err)
+	}
+	done(success)
+}
+
+// trip 连续上报失败直到熔断器打开
+func trip(t *testing.T, b *Breaker) {
+	t.Helper()
+	for i := 0; i < 3; i++ {
+		call(t, b, false)
+	}
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("连续失败达到阈值后应打开, State() = %s", got)
+	}
+}
+
+func TestOpensAfterConsecutiveFailures(t *testing.T) {
+	b := newTestBreaker(t, 1)
+
+	// 中间有一次成功，连续失败次数清零
+	call(t, b, false)
+	call(t, b, false)
+	call(t, b, true)
+	call(t, b, false)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("失败不连续时不应打开, State() = %s", got)
+	}
+
+	call(t, b, false)
+	call(t, b, false)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("State() = %s, want open", got)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("打开状态应快速失败, Allow() = %v", err)
+	}
+}
+
+func TestStateReportsHalfOpenAfterTimeout(t *testing.T) {
+	b := newTestBreaker(t, 1)
+	trip(t, b)
+
+	time.Sleep(openTimeout)
+	// 只看 State 的调用方也要能发现冷却已结束，否则没有请求走到 Allow，熔断器永远不会恢复
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("冷却结束后 State() = %s, want half-open", got)
+	}
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("冷却结束后应放行探测请求: %v", err)
+	}
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("State() = %s, want half-open", got)
+	}
+}
+
+func TestHalfOpenLimitsProbes(t *testing.T) {
+	b := newTestBreaker(t, 2)
+	trip(t, b)
+	time.Sleep(openTimeout)
+
+	var probes []func(bool)
+	for i := 0; i < 2; i++ {
+		done, err := b.Allow()
+		if err != nil {
+			t.Fatalf("第 %d 个探测请求应放行: %v", i+1, err)
+		}
+		probes = append(probes, done)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("超过探测数量应快速失败, Allow() = %v", err)
+	}
+
+	// 全部探测成功后才恢复
+	probes[0](true)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("State() = %s, want half-open", got)
+	}
+	probes[1](true)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("探测全部成功后应关闭, State() = %s", got)
+	}
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("关闭后应当放行: %v", err)
+	}
+}
+
+func TestHalfOpenProbeFailureReopens(t *testing.T) {
+	b := newTestBreaker(t, 1)
+	trip(t, b)
+	time.Sleep(openTimeout)
+
+	call(t, b, false)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("探测失败后应重新打开, State() = %s", got)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("重新打开后应重新计算冷却时间, Allow() = %v", err)
+	}
+}
+
+func TestLateResultsAreNotProbes(t *testing.T) {
+	b := newTestBreaker(t, 1)
+
+	// 关闭状态放行的慢请求，熔断器打开、进入半开后才返回
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	trip(t, b)
+	time.Sleep(openTimeout)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("冷却结束后应放行探测请求: %v", err)
+	}
+
+	slow(true)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("非探测请求的结果不应让熔断器关闭, State() = %s", got)
+	}
+	probe(true)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("探测成功后应关闭, State() = %s", got)
+	}
+}
+
+func TestDo(t *testing.T) {
+	b := newTestBreaker(t, 1)
+	errDown := errors.New("down")
+
+	for i := 0; i < 3; i++ {
+		if err := b.Do(func() error { return errDown }); !errors.Is(err, errDown) {
+			t.Fatalf("Do 应返回 fn 的错误, got %v", err)
+		}
+	}
+	called := false
+	err := b.Do(func() error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrOpen) || called {
+		t.Fatalf("熔断器打开后不应执行 fn, err = %v, called = %v", err, called)
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	b := newTestBreaker(t, 1)
+	call(t, b, false)
+
+	// 热更新调高阈值后，已有的失败计数保留，按新阈值判断
+	b.update(config.BreakerConfig{FailureThreshold: 5, OpenTimeout: openTimeout})
+	for i := 0; i < 3; i++ {
+		call(t, b, false)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("未达到新阈值不应打开, State() = %s", got)
+	}
+	call(t, b, false)
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("达到新阈值后应打开, State() = %s", got)
+	}
+}
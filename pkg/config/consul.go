@@ -0,0 +1,177 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// Consul KV 远程配置
+// =============================================================================
+// KV 布局与配置文件的层级一一对应，前缀之后的路径即配置项:
+//   seckill/config/server/mode                         -> server.mode
+//   seckill/config/rate_limit/routes/seckill_buy/user/rate -> rate_limit.routes.seckill_buy.user.rate
+// 值统一按字符串存储，解析时与配置文件一样做类型转换（数字、时长、逗号分隔列表）
+
+var (
+	remoteSettings map[string]interface{} // 最后一次成功拉取的远程配置（嵌套 map）
+	remoteLock     sync.RWMutex
+)
+
+// consulKVPair Consul KV 接口返回的单个键值
+type consulKVPair struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"` // 接口返回 base64，json 解码时自动还原
+}
+
+// remoteSnapshot 返回最后一次成功拉取的远程配置
+func remoteSnapshot() map[string]interface{} {
+	remoteLock.RLock()
+	defer remoteLock.RUnlock()
+	return remoteSettings
+}
+
+// startRemote 首次同步拉取远程配置，然后在后台用阻塞查询持续监听变化
+// Consul 不可用时不影响启动，继续使用文件配置，后台会一直重试
+func startRemote(cfg ConsulConfig) {
+	index, err := syncRemote(cfg, 0, 5*time.Second)
+	if err != nil {
+		fmt.Printf("拉取 Consul 远程配置失败，先使用本地配置: %v\n", err)
+	} else {
+		fmt.Printf("✅ Consul 远程配置已加载 [%s]\n", cfg.KVPrefix)
+	}
+
+	go watchRemote(cfg, index)
+}
+
+// watchRemote 循环发起阻塞查询，KV 有变化时走与文件变更相同的热更新流程
+func watchRemote(cfg ConsulConfig, index uint64) {
+	backoff := time.Second
+	for {
+		next, err := syncRemote(cfg, index, cfg.KVWaitTime)
+		if err != nil {
+			// 拉取失败时保留上一次成功的远程配置，退避重试
+			fmt.Printf("监听 Consul 远程配置失败，%s 后重试: %v\n", backoff, err)
+			time.Sleep(backoff)
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+		index = next
+	}
+}
+
+// syncRemote 拉取一次远程配置，index 变化时更新快照并触发热更新
+// 返回本次拿到的 Consul 索引，供下一次阻塞查询使用
+func syncRemote(cfg ConsulConfig, index uint64, wait time.Duration) (uint64, error) {
+	pairs, next, err := fetchKV(cfg, index, wait)
+	if err != nil {
+		return index, err
+	}
+	// 阻塞查询超时返回时索引不变，说明没有变化
+	if next == index && index != 0 {
+		return index, nil
+	}
+	// 索引回退（如 Consul 重建）时从头开始
+	if next < index {
+		next = 0
+	}
+
+	remoteLock.Lock()
+	previous := remoteSettings
+	remoteSettings = buildSettings(cfg.KVPrefix, pairs)
+	remoteLock.Unlock()
+
+	// 新的远程配置无法解析时回退到上一份快照，保证后续文件热更新不受影响
+	if err := Reload("consul"); err != nil {
+		remoteLock.Lock()
+		remoteSettings = previous
+		remoteLock.Unlock()
+	}
+	return next, nil
+}
+
+// fetchKV 调用 Consul HTTP API 读取前缀下的全部键
+// index 非 0 时为阻塞查询，Consul 会挂起请求直到数据变化或等待超时
+func fetchKV(cfg ConsulConfig, index uint64, wait time.Duration) ([]consulKVPair, uint64, error) {
+	addr := cfg.Addr
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	query := url.Values{}
+	query.Set("recurse", "true")
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", wait.String())
+	}
+	reqURL := fmt.Sprintf("%s/v1/kv/%s?%s", strings.TrimRight(addr, "/"),
+		strings.Trim(cfg.KVPrefix, "/"), query.Encode())
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, index, err
+	}
+	if cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", cfg.Token)
+	}
+
+	// Consul 会在 wait 基础上增加少量抖动，超时时间留出余量
+	client := &http.Client{Timeout: wait + 10*time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, index, err
+	}
+	defer resp.Body.Close()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var pairs []consulKVPair
+		if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+			return nil, index, fmt.Errorf("解析 Consul 响应失败: %w", err)
+		}
+		return pairs, next, nil
+	case http.StatusNotFound:
+		// 前缀下没有任何键，等同于没有远程配置
+		return nil, next, nil
+	default:
+		return nil, index, fmt.Errorf("Consul 返回异常状态码: %d", resp.StatusCode)
+	}
+}
+
+// buildSettings 把 KV 列表转换为与配置文件结构一致的嵌套 map
+func buildSettings(prefix string, pairs []consulKVPair) map[string]interface{} {
+	prefix = strings.Trim(prefix, "/") + "/"
+	settings := map[string]interface{}{}
+	for _, p := range pairs {
+		// 跳过目录节点和空值
+		if strings.HasSuffix(p.Key, "/") || p.Value == nil {
+			continue
+		}
+		path := strings.TrimPrefix(p.Key, prefix)
+		if path == p.Key || path == "" {
+			continue
+		}
+
+		parts := strings.Split(strings.ToLower(path), "/")
+		node := settings
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = string(p.Value)
+	}
+	return settings
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// =============================================================================
+// 生效配置导出（脱敏）
+// =============================================================================
+
+// redactedMask 敏感配置的替换值
+const redactedMask = "******"
+
+// sensitiveKeys 需要脱敏的配置项（mapstructure 路径）
+var sensitiveKeys = map[string]bool{
+	"mysql.password":         true,
+	"mysql.replica.password": true,
+	"redis.password":         true,
+	"consul.token":           true,
+
+	"payment.mock.secret": true,
+}
+
+// sensitiveURLKeys 连接串里带账号密码的配置项，只隐藏密码部分
+var sensitiveURLKeys = map[string]bool{
+	"rabbitmq.url": true,
+}
+
+// Effective 返回合并所有配置层后的最终生效配置，敏感项已脱敏
+// 键名与配置文件保持一致，用于管理接口和命令行排查配置问题
+func Effective() map[string]interface{} {
+	cfg := Get()
+	out, _ := toMap(reflect.ValueOf(*cfg), "").(map[string]interface{})
+	return out
+}
+
+// toMap 按 mapstructure 标签把配置结构体转换为 map，同时处理脱敏
+func toMap(v reflect.Value, path string) interface{} {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			key := t.Field(i).Tag.Get("mapstructure")
+			if key == "" || key == "-" {
+				continue
+			}
+			m[key] = toMap(v.Field(i), joinKey(path, key))
+		}
+		return m
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			m[key] = toMap(iter.Value(), joinKey(path, key))
+		}
+		return m
+	case reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list[i] = toMap(v.Index(i), path)
+		}
+		return list
+	case reflect.String:
+		return redact(path, v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+// redact 对敏感配置项脱敏，空值保持原样以便看出“未配置”
+func redact(path, val string) string {
+	if val == "" {
+		return val
+	}
+	if sensitiveKeys[path] {
+		return redactedMask
+	}
+	if sensitiveURLKeys[path] {
+		u, err := url.Parse(val)
+		if err != nil {
+			return redactedMask
+		}
+		return u.Redacted()
+	}
+	return val
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
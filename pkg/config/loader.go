@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// =============================================================================
+// 分层加载
+// =============================================================================
+
+// EnvName 选择环境配置文件的环境变量，如 SECKILL_ENV=prod 会加载 config.prod.yaml
+const EnvName = "SECKILL_ENV"
+
+var (
+	baseFile    string     // 基础配置文件（首次加载后为实际使用的路径）
+	profileFile string     // 环境配置文件（未设置 SECKILL_ENV 时为空）
+	reloadLock  sync.Mutex // 串行化加载，避免并发热更新互相覆盖
+)
+
+// Env 当前环境名（SECKILL_ENV），未设置时为空
+func Env() string {
+	return strings.TrimSpace(os.Getenv(EnvName))
+}
+
+// Files 返回参与合并的配置文件，按加载顺序排列
+func Files() []string {
+	files := []string{baseFile}
+	if profileFile != "" {
+		files = append(files, profileFile)
+	}
+	return files
+}
+
+// load 按 基础文件 -> 环境文件 -> 远程配置 -> 环境变量 -> 密钥文件 的顺序构建一份完整配置
+func load() (*Config, error) {
+	v := viper.New()
+
+	// 1. 基础配置文件
+	if baseFile != "" {
+		v.SetConfigFile(baseFile)
+	} else {
+		// 默认配置文件位置
+		v.SetConfigName("config")        // 配置文件名（不带后缀）
+		v.SetConfigType("yaml")          // 配置文件类型
+		v.AddConfigPath(".")             // 当前目录
+		v.AddConfigPath("./config")      // config 目录
+		v.AddConfigPath("../config")     // 上级 config 目录
+		v.AddConfigPath("/etc/seckill/") // Linux 系统配置目录
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	baseFile = v.ConfigFileUsed()
+
+	// 2. 环境配置文件，覆盖基础配置中的同名项
+	profileFile = ""
+	if env := Env(); env != "" {
+		profileFile = profilePath(baseFile, env)
+		v.SetConfigFile(profileFile)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("读取环境配置文件失败 [%s]: %w", profileFile, err)
+		}
+	}
+
+	// 3. Consul KV 远程配置（使用最后一次成功拉取的快照）
+	if remote := remoteSnapshot(); len(remote) > 0 {
+		if err := v.MergeConfigMap(remote); err != nil {
+			return nil, fmt.Errorf("合并远程配置失败: %w", err)
+		}
+	}
+
+	// 4. 环境变量覆盖
+	// 示例: SECKILL_SERVER_PORT=9090 会覆盖 server.port
+	v.SetEnvPrefix("SECKILL")                          // 环境变量前缀
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_")) // server.port -> SERVER_PORT
+	v.AutomaticEnv()                                   // 自动读取环境变量
+
+	// 5. 密钥文件覆盖
+	if err := applySecretFiles(v); err != nil {
+		return nil, err
+	}
+
+	// 6. 解析到结构体
+	cfg := &Config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	// 7. 设置默认值（如果各层都没有配置）
+	setDefaults(cfg)
+	return cfg, nil
+}
+
+// profilePath 根据基础文件推导环境配置文件路径
+// config/config.yaml + prod -> config/config.prod.yaml
+func profilePath(base, env string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "." + env + ext
+}
+
+// applySecretFiles 从文件读取敏感配置
+// 示例: SECKILL_MYSQL_PASSWORD_FILE=/run/secrets/mysql-password 会用文件内容覆盖 mysql.password
+func applySecretFiles(v *viper.Viper) error {
+	for _, key := range v.AllKeys() {
+		envKey := "SECKILL_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")) + "_FILE"
+		path := os.Getenv(envKey)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取密钥文件失败 [%s]: %w", envKey, err)
+		}
+		// Secret 文件末尾常带换行，去掉以免混入密码
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
+
+// =============================================================================
+// 热更新
+// =============================================================================
+
+// Reload 重新加载全部配置层
+// 加载失败时保留上一次成功的配置，不影响正在运行的服务
+func Reload(reason string) error {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	cfg, err := load()
+	if err != nil {
+		fmt.Printf("重新加载配置失败(%s): %v\n", reason, err)
+		return err
+	}
+
+	// 整体替换，正在处理的请求继续使用旧配置
+	current.Store(cfg)
+
+	fmt.Printf("配置已热更新(%s)\n", reason)
+	return nil
+}
+
+// watchFiles 监听基础文件和环境文件所在目录
+// 监听目录而不是文件本身，才能感知 K8s ConfigMap 通过软链接整体替换文件
+func watchFiles() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	watched := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, f := range Files() {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		watched[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !e.Has(fsnotify.Write) && !e.Has(fsnotify.Create) {
+					continue
+				}
+				// ..data 是 ConfigMap 挂载目录里指向当前版本的软链接
+				if watched[filepath.Clean(e.Name)] || filepath.Base(e.Name) == "..data" {
+					fmt.Printf("配置文件变更: %s\n", e.Name)
+					Reload("file")
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Printf("配置文件监听出错: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -14,11 +14,43 @@ import (
 
 var DB *gorm.DB
 
+// ReadDB 只读副本连接，未配置副本时为 nil
+var ReadDB *gorm.DB
+
 func InitMySQL() {
 	cfg := config.Get().MySQL
 
-	// 使用配置生成 DSN
-	dsn := cfg.DSN()
+	var err error
+	DB, err = open(cfg.DSN())
+	if err != nil {
+		log.Fatalf("连接 MySQL 失败: %v", err)
+	}
+	fmt.Printf("✅ MySQL 连接成功 [%s:%d/%s]\n", cfg.Host, cfg.Port, cfg.Database)
+
+	// 只读副本连接失败时不影响启动，读请求退回主库
+	if dsn := cfg.ReplicaDSN(); dsn != "" {
+		ReadDB, err = open(dsn)
+		if err != nil {
+			log.Printf("⚠️ 连接 MySQL 只读副本失败，读请求使用主库: %v", err)
+			ReadDB = nil
+		} else {
+			fmt.Printf("✅ MySQL 只读副本连接成功 [%s]\n", cfg.Replica.Host)
+		}
+	}
+}
+
+// Reader 返回用于只读查询的连接：配置了只读副本时使用副本，否则使用主库
+// 副本存在复制延迟，刚写入就要读取的场景（如下单后立即查询）应使用 DB
+func Reader() *gorm.DB {
+	if ReadDB != nil {
+		return ReadDB
+	}
+	return DB
+}
+
+// open 按配置的日志级别和连接池参数打开连接
+func open(dsn string) (*gorm.DB, error) {
+	cfg := config.Get().MySQL
 
 	// 根据配置设置日志级别
 	var logLevel logger.LogLevel
@@ -33,20 +65,20 @@ func InitMySQL() {
 		logLevel = logger.Info
 	}
 
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 	})
-
 	if err != nil {
-		log.Fatalf("连接 MySQL 失败: %v", err)
+		return nil, err
 	}
 
-	sqlDB, _ := DB.DB()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
 	// 从配置读取连接池参数
 	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
-
-	fmt.Printf("✅ MySQL 连接成功 [%s:%d/%s]\n", cfg.Host, cfg.Port, cfg.Database)
+	return db, nil
 }
@@ -0,0 +1,148 @@
+package limiter
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 自适应并发限流（梯度算法，参考 Netflix concurrency-limits Gradient2 / BBR）
+// 思路: 请求延迟相对基线膨胀，说明下游（Redis/MySQL）开始排队，此时收缩并发上限；
+// 延迟恢复后再逐步放大。超出上限的请求直接拒绝，保证已放行的请求仍能在 SLO 内完成
+
+// Options 自适应限流参数
+type Options struct {
+	InitialLimit int           // 初始并发上限
+	MinLimit     int           // 并发上限下限
+	MaxLimit     int           // 并发上限上限
+	Tolerance    float64       // 允许的延迟膨胀倍数，当前延迟超过 基线*Tolerance 时开始收缩
+	Smoothing    float64       // 每个窗口调整上限的平滑系数 (0,1]
+	Window       time.Duration // 采样窗口，每个窗口结束时调整一次上限
+	MinSamples   int           // 窗口内最少样本数，样本太少时不调整
+	SLO          time.Duration // 目标延迟，窗口平均延迟超过 SLO 时按比例收缩（0 表示不启用）
+}
+
+// Adaptive 自适应并发限流器，并发安全
+type Adaptive struct {
+	opts     Options
+	inflight int64 // 当前在途请求数
+	limit    int64 // 当前并发上限（取整后供 Acquire 无锁读取）
+
+	mu          sync.Mutex
+	estimate    float64   // 当前并发上限（浮点，用于平滑计算）
+	longRTT     float64   // 长期延迟基线（纳秒，指数移动平均）
+	sum         float64   // 窗口内延迟之和（纳秒）
+	count       int       // 窗口内样本数
+	maxInflight int64     // 窗口内观察到的最大在途请求数
+	windowStart time.Time // 窗口开始时间
+}
+
+// NewAdaptive 创建自适应限流器，未设置的参数使用默认值
+func NewAdaptive(opts Options) *Adaptive {
+	if opts.InitialLimit <= 0 {
+		opts.InitialLimit = 100
+	}
+	if opts.MinLimit <= 0 {
+		opts.MinLimit = 10
+	}
+	if opts.MaxLimit < opts.InitialLimit {
+		opts.MaxLimit = opts.InitialLimit * 10
+	}
+	if opts.Tolerance < 1 {
+		opts.Tolerance = 2
+	}
+	if opts.Smoothing <= 0 || opts.Smoothing > 1 {
+		opts.Smoothing = 0.2
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Second
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = 10
+	}
+	return &Adaptive{
+		opts:        opts,
+		limit:       int64(opts.InitialLimit),
+		estimate:    float64(opts.InitialLimit),
+		windowStart: time.Now(),
+	}
+}
+
+// Acquire 尝试获取一个并发名额
+// ok 为 false 表示已超过当前上限，应直接拒绝请求；
+// ok 为 true 时请求处理完必须调用 done，用于释放名额并上报延迟
+func (a *Adaptive) Acquire() (done func(), ok bool) {
+	inflight := atomic.AddInt64(&a.inflight, 1)
+	if inflight > atomic.LoadInt64(&a.limit) {
+		atomic.AddInt64(&a.inflight, -1)
+		return nil, false
+	}
+
+	start := time.Now()
+	return func() {
+		atomic.AddInt64(&a.inflight, -1)
+		a.record(time.Since(start), inflight)
+	}, true
+}
+
+// Limit 当前并发上限
+func (a *Adaptive) Limit() int {
+	return int(atomic.LoadInt64(&a.limit))
+}
+
+// Inflight 当前在途请求数
+func (a *Adaptive) Inflight() int {
+	return int(atomic.LoadInt64(&a.inflight))
+}
+
+// record 记录一个样本，窗口结束时调整并发上限
+func (a *Adaptive) record(rtt time.Duration, inflight int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sum += float64(rtt)
+	a.count++
+	if inflight > a.maxInflight {
+		a.maxInflight = inflight
+	}
+
+	if time.Since(a.windowStart) < a.opts.Window || a.count < a.opts.MinSamples {
+		return
+	}
+	a.update(a.sum/float64(a.count), a.maxInflight)
+
+	// 开启新窗口
+	a.sum, a.count, a.maxInflight = 0, 0, 0
+	a.windowStart = time.Now()
+}
+
+// update 根据窗口平均延迟与长期基线的比值调整并发上限
+func (a *Adaptive) update(shortRTT float64, maxInflight int64) {
+	// 1. 更新长期基线
+	if a.longRTT == 0 {
+		a.longRTT = shortRTT
+	} else {
+		a.longRTT = a.longRTT*0.9 + shortRTT*0.1
+	}
+	// 持续高负载时基线会被慢慢抬高，延迟明显回落后让基线跟着回落
+	if a.longRTT/shortRTT > 2 {
+		a.longRTT *= 0.95
+	}
+
+	// 2. 计算梯度：延迟膨胀越多，梯度越小（0.5~1）
+	gradient := math.Max(0.5, math.Min(1, a.opts.Tolerance*a.longRTT/shortRTT))
+	if a.opts.SLO > 0 && shortRTT > float64(a.opts.SLO) {
+		gradient = math.Max(0.5, math.Min(gradient, float64(a.opts.SLO)/shortRTT))
+	}
+
+	// 3. 计算新上限，sqrt(limit) 作为允许的排队余量，让上限在延迟正常时能继续增长
+	newLimit := a.estimate*gradient + math.Sqrt(a.estimate)
+	// 并发远未用满时延迟不能说明容量，不再放大上限，避免空闲期上限无限膨胀
+	if newLimit > a.estimate && float64(maxInflight) < a.estimate/2 {
+		return
+	}
+	a.estimate = a.estimate*(1-a.opts.Smoothing) + newLimit*a.opts.Smoothing
+	a.estimate = math.Max(float64(a.opts.MinLimit), math.Min(float64(a.opts.MaxLimit), a.estimate))
+	atomic.StoreInt64(&a.limit, int64(a.estimate))
+}
@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+const ms = float64(time.Millisecond)
+
+func TestAcquireRejectsAboveLimit(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 2})
+
+	done1, ok1 := a.Acquire()
+	_, ok2 := a.Acquire()
+	if !ok1 || !ok2 {
+		t.Fatal("上限以内的请求应当放行")
+	}
+	if _, ok := a.Acquire(); ok {
+		t.Fatal("超过上限的请求应当拒绝")
+	}
+	if got := a.Inflight(); got != 2 {
+		t.Fatalf("拒绝的请求不应计入在途数, Inflight() = %d", got)
+	}
+
+	done1()
+	if _, ok := a.Acquire(); !ok {
+		t.Fatal("释放名额后应当放行")
+	}
+}
+
+func TestUpdateGrowsWhenLatencyIsStable(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 100, MaxLimit: 1000})
+	for i := 0; i < 5; i++ {
+		before := a.Limit()
+		a.update(10*ms, int64(a.Limit()))
+		if a.Limit() <= before {
+			t.Fatalf("第 %d 个窗口: 延迟稳定且并发用满时上限应增长, %d -> %d", i, before, a.Limit())
+		}
+	}
+}
+
+func TestUpdateDoesNotGrowWhenIdle(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 100, MaxLimit: 1000})
+	for i := 0; i < 5; i++ {
+		a.update(10*ms, 10)
+	}
+	if got := a.Limit(); got != 100 {
+		t.Fatalf("并发远未用满时上限不应增长, Limit() = %d", got)
+	}
+}
+
+func TestUpdateShrinksOnLatencyInflation(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 100, MaxLimit: 1000})
+	a.update(10*ms, 100) // 建立基线
+	before := a.Limit()
+	a.update(100*ms, 100)
+	if a.Limit() >= before {
+		t.Fatalf("延迟膨胀时上限应收缩, %d -> %d", before, a.Limit())
+	}
+}
+
+func TestUpdateShrinksAboveSLO(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 100, Smoothing: 1, SLO: 5 * time.Millisecond})
+	// 基线刚建立，梯度本应为 1；平均延迟是 SLO 的两倍，梯度降为 0.5
+	a.update(10*ms, 100)
+	if got := a.Limit(); got != 60 { // 100*0.5 + sqrt(100)
+		t.Fatalf("超过 SLO 时应按比例收缩, Limit() = %d, want 60", got)
+	}
+}
+
+func TestUpdateClampsToMinAndMax(t *testing.T) {
+	a := NewAdaptive(Options{InitialLimit: 100, MaxLimit: 105, Smoothing: 1})
+	a.update(10*ms, 100)
+	if got := a.Limit(); got != 105 {
+		t.Fatalf("上限不应超过 MaxLimit, Limit() = %d", got)
+	}
+
+	a = NewAdaptive(Options{InitialLimit: 20, MinLimit: 18, Smoothing: 1})
+	a.update(10*ms, 20)
+	a.update(1000*ms, int64(a.Limit()))
+	if got := a.Limit(); got != 18 {
+		t.Fatalf("上限不应低于 MinLimit, Limit() = %d", got)
+	}
+}
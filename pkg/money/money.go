@@ -0,0 +1,116 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 金额，以分为单位的整数，避免浮点运算的舍入误差
+// 数据库存 bigint（分），JSON 输出为保留两位小数的数字（元），如 12.30
+type Money int64
+
+var ErrInvalidAmount = errors.New("金额格式错误，最多两位小数")
+
+// FromYuan 把浮点数（元）四舍五入到分，只用于常量和旧数据转换，运算不要经过浮点
+func FromYuan(yuan float64) Money {
+	return Money(math.Round(yuan * 100))
+}
+
+// Parse 精确解析十进制金额（元），如 "12.3"、"-0.05"，小数超过两位时返回错误
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" || len(fracPart) > 2 || intPart[0] == '+' || intPart[0] == '-' {
+		return 0, ErrInvalidAmount
+	}
+	yuan, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	fen := int64(0)
+	if fracPart != "" {
+		fracPart += strings.Repeat("0", 2-len(fracPart))
+		if fen, err = strconv.ParseInt(fracPart, 10, 64); err != nil || fracPart[0] == '+' || fracPart[0] == '-' {
+			return 0, ErrInvalidAmount
+		}
+	}
+	// yuan*100 + fen 不能溢出 int64
+	if yuan > (math.MaxInt64-fen)/100 {
+		return 0, ErrInvalidAmount
+	}
+	m := Money(yuan*100 + fen)
+	if neg {
+		m = -m
+	}
+	return m, nil
+}
+
+// Cents 金额（分）
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Mul 乘以数量
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// String 保留两位小数的金额（元），如 "12.30"
+func (m Money) String() string {
+	sign := ""
+	v := uint64(m)
+	if m < 0 {
+		// 按无符号取绝对值，math.MinInt64 取反不会溢出
+		sign, v = "-", uint64(-(m+1))+1
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// MarshalJSON 输出为 JSON 数字（元），不经过浮点转换
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 接受 JSON 数字或字符串（元）
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value 写入数据库（分）
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Scan 从数据库读取（分）
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*m = Money(v)
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("金额字段不是整数（分）: %q", v)
+		}
+		*m = Money(n)
+	case nil:
+		*m = 0
+	default:
+		return fmt.Errorf("不支持的金额类型 %T", src)
+	}
+	return nil
+}
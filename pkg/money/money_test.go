@@ -0,0 +1,114 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := map[string]Money{
+		"0":                    0,
+		"12":                   1200,
+		"12.":                  1200,
+		"12.3":                 1230,
+		"12.30":                1230,
+		"0.1":                  10,
+		"0.01":                 1,
+		"-0.05":                -5,
+		" 8999 ":               899900,
+		"92233720368547758.07": math.MaxInt64,
+	}
+	for in, want := range valid {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"", "-", "abc", "1.234", ".5", "+1", "1.+1", "1.-1", "1.a", "1e5", "--1",
+		// 整数部分不溢出，加上小数部分后溢出
+		"92233720368547758.08",
+		"92233720368547758.99",
+		"92233720368547759",
+		"99999999999999999999",
+	}
+	for _, in := range invalid {
+		if got, err := Parse(in); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%q) = %d, %v, want ErrInvalidAmount", in, got, err)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := map[Money]string{
+		0:             "0.00",
+		5:             "0.05",
+		1230:          "12.30",
+		-105:          "-1.05",
+		math.MaxInt64: "92233720368547758.07",
+		math.MinInt64: "-92233720368547758.08",
+	}
+	for m, want := range tests {
+		if got := m.String(); got != want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(m), got, want)
+		}
+	}
+	if got := FromYuan(0.29); got != 29 {
+		t.Errorf("FromYuan(0.29) = %d, want 29", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Number Money  `json:"number"`
+		String Money  `json:"string"`
+		Null   Money  `json:"null"`
+		Ptr    *Money `json:"ptr"`
+	}
+	v.Null = 7
+	if err := json.Unmarshal([]byte(`{"number":0.1,"string":"19.99","null":null,"ptr":-3}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Number != 10 || v.String != 1999 || v.Null != 7 || v.Ptr == nil || *v.Ptr != -300 {
+		t.Fatalf("Unmarshal = %+v", v)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"number":0.10,"string":19.99,"null":0.07,"ptr":-3.00}`; string(out) != want {
+		t.Fatalf("Marshal = %s, want %s", out, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"number":1.005}`), &v); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("超过两位小数应返回 ErrInvalidAmount, got %v", err)
+	}
+}
+
+func TestScanValue(t *testing.T) {
+	var m Money
+	if err := m.Scan(int64(1230)); err != nil || m != 1230 {
+		t.Errorf("Scan(int64) = %d, %v", m, err)
+	}
+	if err := m.Scan([]byte("-5")); err != nil || m != -5 {
+		t.Errorf("Scan([]byte) = %d, %v", m, err)
+	}
+	if err := m.Scan(nil); err != nil || m != 0 {
+		t.Errorf("Scan(nil) = %d, %v", m, err)
+	}
+	if err := m.Scan([]byte("12.30")); err == nil {
+		t.Error("Scan 小数字符串应返回错误")
+	}
+	if err := m.Scan(12.3); err == nil {
+		t.Error("Scan(float64) 应返回错误")
+	}
+
+	v, err := Money(1230).Value()
+	if err != nil || v != int64(1230) {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+}
@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"seckill/pkg/config"
+	"seckill/pkg/money"
+)
+
+// 模拟支付：本地联调使用，不对接真实渠道
+// 创建支付单时返回模拟支付链接，访问该链接即视为支付成功，并按真实渠道的方式构造签名回调
+// 退款直接同步成功
+
+// 模拟支付回调的请求头
+const (
+	HeaderTimestamp = "X-Pay-Timestamp"
+	HeaderSignature = "X-Pay-Signature"
+)
+
+// MockProvider 模拟支付渠道
+type MockProvider struct {
+	secret string
+}
+
+// NewMockProvider 创建模拟支付渠道，secret 为回调签名密钥
+func NewMockProvider(secret string) *MockProvider {
+	return &MockProvider{secret: secret}
+}
+
+func (m *MockProvider) Name() string {
+	return "mock"
+}
+
+// Create 模拟渠道下单，交易号由支付单号生成
+func (m *MockProvider) Create(ctx context.Context, req Request) (*Intent, error) {
+	return &Intent{
+		TradeNo: "MOCK" + req.PaymentNo,
+		PayURL:  fmt.Sprintf("/api/payments/mock/%s/pay", req.PaymentNo),
+	}, nil
+}
+
+// VerifyCallback 校验回调签名和时间戳
+func (m *MockProvider) VerifyCallback(header http.Header, body []byte) (*Callback, error) {
+	tolerance := config.Get().Payment.CallbackTolerance
+	if err := Verify(m.secret, header.Get(HeaderTimestamp), body, header.Get(HeaderSignature), tolerance); err != nil {
+		return nil, err
+	}
+	var cb Callback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &cb, nil
+}
+
+// Refund 模拟渠道退款，同步完成
+func (m *MockProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return &RefundResult{
+		ProviderRefundNo: "MOCKR" + req.RefundNo,
+		Completed:        true,
+	}, nil
+}
+
+// VerifyRefundCallback 校验退款回调签名和时间戳
+func (m *MockProvider) VerifyRefundCallback(header http.Header, body []byte) (*RefundCallback, error) {
+	tolerance := config.Get().Payment.CallbackTolerance
+	if err := Verify(m.secret, header.Get(HeaderTimestamp), body, header.Get(HeaderSignature), tolerance); err != nil {
+		return nil, err
+	}
+	var cb RefundCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return &cb, nil
+}
+
+// SignedCallback 构造一次支付成功的签名回调，模拟渠道通知
+func (m *MockProvider) SignedCallback(paymentNo string, amount money.Money) (http.Header, []byte) {
+	body, _ := json.Marshal(Callback{
+		PaymentNo: paymentNo,
+		TradeNo:   "MOCK" + paymentNo,
+		Amount:    amount,
+		Success:   true,
+		PaidAt:    time.Now(),
+	})
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	header := http.Header{}
+	header.Set(HeaderTimestamp, ts)
+	header.Set(HeaderSignature, Sign(m.secret, ts, body))
+	return header, body
+}
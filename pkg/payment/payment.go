@@ -0,0 +1,128 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"seckill/pkg/config"
+	"seckill/pkg/money"
+)
+
+// 支付渠道：统一的下单和回调验签接口，具体渠道（支付宝、微信、模拟支付）各自实现
+// 订单与支付单的状态流转在 service 层处理，这里只负责与渠道交互
+
+var (
+	ErrProviderNotFound = errors.New("不支持的支付渠道")
+	ErrInvalidSignature = errors.New("回调签名校验失败")
+	ErrCallbackExpired  = errors.New("回调时间戳已过期")
+
+	// ErrRefundRejected 渠道明确拒绝退款（余额不足、交易已关闭等），渠道实现应包装此错误返回
+	// 超时、网络错误等其他错误表示结果未知，渠道可能已经受理
+	ErrRefundRejected = errors.New("渠道拒绝退款")
+)
+
+// Request 创建支付单的参数
+type Request struct {
+	PaymentNo string      // 本系统支付单号，渠道回调时原样带回
+	OrderNum  string      // 订单号
+	Amount    money.Money // 支付金额
+	Subject   string      // 商品描述
+	ExpireAt  time.Time   // 支付截止时间，渠道侧超时关闭
+	NotifyURL string      // 回调地址
+}
+
+// Intent 渠道返回的支付信息，客户端据此拉起支付
+type Intent struct {
+	TradeNo string `json:"trade_no"` // 渠道交易号
+	PayURL  string `json:"pay_url"`  // 支付链接
+}
+
+// Callback 验签后的回调内容
+type Callback struct {
+	PaymentNo string      `json:"payment_no"`
+	TradeNo   string      `json:"trade_no"`
+	Amount    money.Money `json:"amount"`
+	Success   bool        `json:"success"`
+	PaidAt    time.Time   `json:"paid_at"`
+}
+
+// RefundRequest 发起退款的参数
+type RefundRequest struct {
+	RefundNo  string      // 本系统退款单号，渠道回调时原样带回
+	PaymentNo string      // 原支付单号
+	TradeNo   string      // 原渠道交易号
+	Amount    money.Money // 退款金额，可以小于支付金额（部分退款）
+	Total     money.Money // 原支付金额
+	Reason    string      // 退款原因
+	NotifyURL string      // 退款结果回调地址
+}
+
+// RefundResult 渠道受理退款的结果
+type RefundResult struct {
+	ProviderRefundNo string // 渠道退款单号
+	Completed        bool   // 渠道同步完成退款；为 false 时等待退款回调
+}
+
+// RefundCallback 验签后的退款回调内容
+type RefundCallback struct {
+	RefundNo         string      `json:"refund_no"`
+	ProviderRefundNo string      `json:"provider_refund_no"`
+	Amount           money.Money `json:"amount"`
+	Success          bool        `json:"success"`
+}
+
+// Provider 支付渠道
+type Provider interface {
+	// Name 渠道名，对应回调地址 /api/payments/callback/{name}
+	Name() string
+	// Create 在渠道创建支付单
+	Create(ctx context.Context, req Request) (*Intent, error)
+	// VerifyCallback 校验回调签名和时间戳，返回回调内容
+	VerifyCallback(header http.Header, body []byte) (*Callback, error)
+	// Refund 对已支付的交易发起退款，渠道异步处理时通过退款回调通知结果
+	// 同一退款单号重复提交时渠道应按幂等处理；明确拒绝时返回 ErrRefundRejected
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	// VerifyRefundCallback 校验退款回调签名和时间戳，返回回调内容
+	VerifyRefundCallback(header http.Header, body []byte) (*RefundCallback, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{}
+)
+
+// Register 注册支付渠道
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name()] = p
+}
+
+// Get 按名称获取支付渠道
+func Get(name string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return p, nil
+}
+
+// Init 按配置注册支付渠道
+func Init() error {
+	cfg := config.Get().Payment
+	if cfg.Mock.Enabled {
+		if config.IsReleaseMode() {
+			return errors.New("生产环境不能启用模拟支付")
+		}
+		if cfg.Mock.Secret == "" {
+			return errors.New("模拟支付未配置回调签名密钥 payment.mock.secret")
+		}
+		Register(NewMockProvider(cfg.Mock.Secret))
+	}
+	return nil
+}
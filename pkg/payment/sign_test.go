@@ -0,0 +1,56 @@
+package payment
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func timestamp(offset time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(offset).Unix(), 10)
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "test-secret"
+	body := []byte(`{"payment_no":"P1","status":"success"}`)
+	now := timestamp(0)
+	sig := Sign(secret, now, body)
+	recent, old, future := timestamp(-30*time.Second), timestamp(-2*time.Minute), timestamp(2*time.Minute)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		want      error
+	}{
+		{"签名正确", secret, now, body, sig, nil},
+		{"时间戳在误差范围内", secret, recent, body, Sign(secret, recent, body), nil},
+		{"密钥不同", "other", now, body, sig, ErrInvalidSignature},
+		{"报文被篡改", secret, now, []byte(`{"payment_no":"P2","status":"success"}`), sig, ErrInvalidSignature},
+		{"时间戳被篡改", secret, timestamp(time.Second), body, sig, ErrInvalidSignature},
+		{"签名为空", secret, now, body, "", ErrInvalidSignature},
+		{"时间戳格式错误", secret, "abc", body, Sign(secret, "abc", body), ErrInvalidSignature},
+		{"时间戳过旧", secret, old, body, Sign(secret, old, body), ErrCallbackExpired},
+		{"时间戳超前", secret, future, body, Sign(secret, future, body), ErrCallbackExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.timestamp, tt.body, tt.signature, time.Minute); err != tt.want {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignIsDeterministic(t *testing.T) {
+	body := []byte(`{}`)
+	a := Sign("k", "1700000000", body)
+	if b := Sign("k", "1700000000", body); a != b {
+		t.Fatalf("相同输入签名不一致: %s != %s", a, b)
+	}
+	if len(a) != 64 {
+		t.Fatalf("HMAC-SHA256 十六进制签名长度应为 64, got %d", len(a))
+	}
+}
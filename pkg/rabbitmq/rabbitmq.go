@@ -2,6 +2,9 @@ package rabbitmq
 
 import (
 	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
 
 	"seckill/pkg/config"
 	"seckill/pkg/logger"
@@ -18,10 +21,40 @@ var Channel *amqp.Channel
 // QueueName 从配置获取队列名称
 var QueueName string
 
+// TimeoutQueueName 订单超时队列名称
+var TimeoutQueueName string
+
+// delayQueueName 订单超时延迟队列名称前缀，实际队列按 TTL 区分（见 delayQueue）
+var delayQueueName string
+
+// deadLetterEx 延迟消息过期后转入的死信交换机
+var deadLetterEx string
+
+// declaredDelayQueues 已声明的延迟队列，按 TTL（毫秒）记录
+var (
+	declaredDelayQueues = map[int64]string{}
+	delayMu             sync.Mutex
+)
+
+// timeoutRetryDelays 超时消息提前到达时重新投递使用的延迟档位（从大到小）
+// 取不超过剩余时长的最大档位，剩余时长不足最小档位时按最小档位投递
+var timeoutRetryDelays = []time.Duration{
+	5 * time.Minute,
+	time.Minute,
+	10 * time.Second,
+	time.Second,
+}
+
+// timeoutRoutingKey 超时消息进入死信交换机后的路由键
+const timeoutRoutingKey = "order.timeout"
+
 // 初始化 RabbitMQ 连接和通道
 func InitRabbitMQ() {
 	cfg := config.Get().RabbitMQ
 	QueueName = cfg.QueueName
+	TimeoutQueueName = cfg.TimeoutQueue
+	delayQueueName = cfg.DelayQueue
+	deadLetterEx = cfg.DeadLetterEx
 
 	// 1.连接 RabbitMQ
 	var err error
@@ -48,21 +81,73 @@ func InitRabbitMQ() {
 	if err != nil {
 		logger.Log.Fatal("声明队列失败", zap.Error(err))
 	}
+
+	// 4.声明订单超时的延迟队列
+	if err := declareTimeoutQueues(); err != nil {
+		logger.Log.Fatal("声明订单超时队列失败", zap.Error(err))
+	}
 	logger.Log.Info("RabbitMQ 初始化并连接成功", zap.String("queue", QueueName))
 }
 
+// declareTimeoutQueues 声明订单超时使用的死信交换机和超时队列
+// 延迟队列没有消费者，消息过期后转入死信交换机，再路由到超时队列
+// RabbitMQ 只检查队头消息是否过期，TTL 不同的消息放在同一个队列里，短 TTL 的消息会被前面长 TTL 的消息阻塞，
+// 所以延迟队列按 TTL 分开声明（队列级 x-message-ttl），同一个队列里的消息总是按投递顺序过期
+func declareTimeoutQueues() error {
+	if err := Channel.ExchangeDeclare(deadLetterEx, "direct", true, false, false, false, nil); err != nil {
+		return err
+	}
+	// 旧版本按消息设置 TTL 的延迟队列，保留声明让存量消息继续过期转入超时队列
+	_, err := Channel.QueueDeclare(delayQueueName, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    deadLetterEx,
+		"x-dead-letter-routing-key": timeoutRoutingKey,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := Channel.QueueDeclare(TimeoutQueueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+	return Channel.QueueBind(TimeoutQueueName, timeoutRoutingKey, deadLetterEx, false, nil)
+}
+
+// delayQueue 返回 TTL 为 ms 毫秒的延迟队列，首次使用时声明
+// 支付超时时间热更新后会声明新的队列，旧队列里的消息按原 TTL 继续过期
+func delayQueue(ms int64) (string, error) {
+	delayMu.Lock()
+	defer delayMu.Unlock()
+	if name, ok := declaredDelayQueues[ms]; ok {
+		return name, nil
+	}
+	name := delayQueueName + "." + strconv.FormatInt(ms, 10) + "ms"
+	_, err := Channel.QueueDeclare(name, true, false, false, false, amqp.Table{
+		"x-message-ttl":             ms,
+		"x-dead-letter-exchange":    deadLetterEx,
+		"x-dead-letter-routing-key": timeoutRoutingKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	declaredDelayQueues[ms] = name
+	return name, nil
+}
+
 // ordermessage定义消息格式
 type OrderMessage struct {
-	UserID    int64 `json:"user_id"`
-	ProductID int64 `json:"product_id"`
+	UserID    int64  `json:"user_id"`
+	SessionID int64  `json:"session_id"` // 秒杀场次ID，商品由场次确定
+	OrderNum  string `json:"order_num"`  // 抢购时生成的订单号，消费者按订单号幂等创建订单
+	AddressID uint   `json:"address_id"` // 收货地址ID，为 0 时使用用户的默认地址
 }
 
 // sendseckillMessage发送消息到队列
-func SendSeckillMessage(uid int64, pid int64) error {
+func SendSeckillMessage(uid int64, sid int64, orderNum string, addressID uint) error {
 	//1、创建消息体
 	msg := OrderMessage{
 		UserID:    uid,
-		ProductID: pid,
+		SessionID: sid,
+		OrderNum:  orderNum,
+		AddressID: addressID,
 	}
 	//转成JSON格式
 	body, _ := json.Marshal(msg)
@@ -82,3 +167,65 @@ func SendSeckillMessage(uid int64, pid int64) error {
 	}
 	return nil
 }
+
+// RetryHeader 下单消息的重试次数，消费者创建订单失败重新投递时递增
+const RetryHeader = "x-retry"
+
+// RetrySeckillMessage 把创建订单失败的消息重新投递到下单队列队尾，retries 为已重试次数
+func RetrySeckillMessage(msg *OrderMessage, retries int32) error {
+	body, _ := json.Marshal(msg)
+	return Channel.Publish(
+		"",
+		QueueName,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Headers:     amqp.Table{RetryHeader: retries},
+			Body:        body,
+		},
+	)
+}
+
+// OrderTimeoutMessage 订单超时消息
+type OrderTimeoutMessage struct {
+	OrderNum string `json:"order_num"`
+}
+
+// SendOrderTimeoutMessage 发送订单超时延迟消息，delay 后进入超时队列
+// delay 应为固定的支付超时时间，每个不同的 delay 都会声明一个延迟队列
+func SendOrderTimeoutMessage(orderNum string, delay time.Duration) error {
+	ms := delay.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	queue, err := delayQueue(ms)
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(OrderTimeoutMessage{OrderNum: orderNum})
+	return Channel.Publish(
+		"",
+		queue,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Body:         body,
+		},
+	)
+}
+
+// RetryOrderTimeoutMessage 超时消息提前到达时重新投递
+// 剩余时长不固定，按 timeoutRetryDelays 档位投递，消息到达后仍未到期会继续按更小的档位投递
+func RetryOrderTimeoutMessage(orderNum string, remaining time.Duration) error {
+	delay := timeoutRetryDelays[len(timeoutRetryDelays)-1]
+	for _, d := range timeoutRetryDelays {
+		if d <= remaining {
+			delay = d
+			break
+		}
+	}
+	return SendOrderTimeoutMessage(orderNum, delay)
+}
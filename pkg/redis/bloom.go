@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BloomFilter 基于 Redis 位图的布隆过滤器，多个副本共享同一份数据
+// 判断为不存在时一定不存在，判断为存在时有少量误判，用于在查库/执行脚本前拦截无效ID
+type BloomFilter struct {
+	key    string
+	bits   uint64 // 位图长度
+	hashes int    // 哈希函数个数
+}
+
+// SessionBloom 秒杀场次ID布隆过滤器，由预热任务和后台创建场次时写入
+var SessionBloom = NewBloomFilter("seckill:bloom:session", 1000000, 0.001)
+
+// NewBloomFilter 按预计元素数量和误判率计算位图长度和哈希函数个数
+func NewBloomFilter(key string, expected uint64, fpRate float64) *BloomFilter {
+	m := math.Ceil(-float64(expected) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(expected) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{key: key, bits: uint64(m), hashes: k}
+}
+
+// offsets 双重哈希计算元素对应的各个位
+func (b *BloomFilter) offsets(item string) []int64 {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+	h2 := fnv.New64()
+	h2.Write([]byte(item))
+	a, c := h1.Sum64(), h2.Sum64()|1
+
+	offsets := make([]int64, b.hashes)
+	for i := range offsets {
+		offsets[i] = int64((a + uint64(i)*c) % b.bits)
+	}
+	return offsets
+}
+
+// Add 把元素加入过滤器，命令写入 pipe，由调用方统一执行
+func (b *BloomFilter) Add(ctx context.Context, pipe redis.Pipeliner, item string) {
+	for _, off := range b.offsets(item) {
+		pipe.SetBit(ctx, b.key, off, 1)
+	}
+}
+
+// MightContain 判断元素是否可能存在，返回 false 表示一定不存在
+// 过滤器还未写入任何数据时（如 Redis 数据丢失后尚未预热）一律返回 true，避免误拦
+func (b *BloomFilter) MightContain(ctx context.Context, item string) (bool, error) {
+	pipe := Client.Pipeline()
+	existsCmd := pipe.Exists(ctx, b.key)
+	offsets := b.offsets(item)
+	cmds := make([]*redis.IntCmd, len(offsets))
+	for i, off := range offsets {
+		cmds[i] = pipe.GetBit(ctx, b.key, off)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if existsCmd.Val() == 0 {
+		return true, nil
+	}
+	for _, cmd := range cmds {
+		if cmd.Val() == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
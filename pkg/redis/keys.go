@@ -0,0 +1,44 @@
+package redis
+
+import "fmt"
+
+// 秒杀相关的 Redis key，统一在这里生成，避免各处拼写不一致
+// 同一场次的 key 使用 {sid} 哈希标签，Redis Cluster 下落在同一个槽，Lua 脚本可以同时操作
+
+// SessionStockKey 场次库存 seckill:session:{sid}:stock（String，剩余库存）
+func SessionStockKey(sessionID uint) string {
+	return fmt.Sprintf("seckill:session:{%d}:stock", sessionID)
+}
+
+// SessionBoughtKey 场次已购记录 seckill:session:{sid}:bought（Hash，用户ID -> 已购数量）
+func SessionBoughtKey(sessionID uint) string {
+	return fmt.Sprintf("seckill:session:{%d}:bought", sessionID)
+}
+
+// SessionInfoKey 场次信息 seckill:session:{sid}:info（Hash，start/end 为 unix 秒，limit 为每人限购数）
+func SessionInfoKey(sessionID uint) string {
+	return fmt.Sprintf("seckill:session:{%d}:info", sessionID)
+}
+
+// SessionResultKey 场次抢购结果 seckill:session:{sid}:result（Hash，用户ID -> 最近一次抢购的订单号和状态 JSON）
+func SessionResultKey(sessionID uint) string {
+	return fmt.Sprintf("seckill:session:{%d}:result", sessionID)
+}
+
+// SessionKeys 秒杀脚本需要的全部 key：库存、已购记录、场次信息、抢购结果
+func SessionKeys(sessionID uint) []string {
+	return []string{
+		SessionStockKey(sessionID),
+		SessionBoughtKey(sessionID),
+		SessionInfoKey(sessionID),
+		SessionResultKey(sessionID),
+	}
+}
+
+// UserEventsKey 用户订单事件流 seckill:events:{uid}（Stream，断线重连时按事件ID补发）
+func UserEventsKey(userID uint) string {
+	return fmt.Sprintf("seckill:events:{%d}", userID)
+}
+
+// OrderEventsChannel 订单事件广播频道，所有副本订阅后推送给各自持有的连接
+const OrderEventsChannel = "seckill:events:order"
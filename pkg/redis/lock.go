@@ -0,0 +1,36 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript 只释放自己持有的锁，避免锁过期后误删其他副本的锁
+var unlockScript = redis.NewScript(`
+	if redis.call('get', KEYS[1]) == ARGV[1] then
+		return redis.call('del', KEYS[1])
+	end
+	return 0
+`)
+
+// TryLock 尝试获取分布式锁（不等待），成功时返回释放函数
+// ttl 为锁的最长持有时间，持有者崩溃后锁自动过期
+func TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, false, err
+	}
+	token := hex.EncodeToString(b)
+
+	ok, err = Client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func() {
+		unlockScript.Run(context.Background(), Client, []string{key}, token)
+	}, true, nil
+}
@@ -37,3 +37,23 @@ func InitRedis() {
 
 	fmt.Printf("✅ Redis 连接成功 [%s]\n", cfg.Addr)
 }
+
+// SessionStocks 批量读取场次库存，返回场次ID到剩余库存的映射，库存 key 不存在的场次不在结果中
+// 各场次的 key 哈希标签不同，Redis Cluster 下不能用 MGET（CROSSSLOT），这里用管道逐个 GET
+func SessionStocks(ctx context.Context, sessionIDs []uint) (map[uint]int64, error) {
+	pipe := Client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(sessionIDs))
+	for i, id := range sessionIDs {
+		cmds[i] = pipe.Get(ctx, SessionStockKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	stocks := make(map[uint]int64, len(sessionIDs))
+	for i, cmd := range cmds {
+		if stock, err := cmd.Int64(); err == nil {
+			stocks[sessionIDs[i]] = stock
+		}
+	}
+	return stocks, nil
+}
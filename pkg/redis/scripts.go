@@ -7,19 +7,48 @@ import "github.com/redis/go-redis/v9"
 
 var SeckillScript *redis.Script
 
-//脚本内容(秒杀核心逻辑)
-//key【1】库存key
-//key【2】用户key
-//arg【1】用户id
+// RateLimitScript 令牌桶限流脚本
+var RateLimitScript *redis.Script
+
+// AdjustStockScript 库存增减脚本
+var AdjustStockScript *redis.Script
+
+// ReleaseScript 归还库存脚本（下单消息发送失败等情况回滚秒杀脚本的扣减）
+var ReleaseScript *redis.Script
+
+// SetResultScript 更新抢购结果脚本
+var SetResultScript *redis.Script
+
+// 脚本内容(秒杀核心逻辑)
+// key【1】场次库存key
+// key【2】场次已购记录key
+// key【3】场次信息key（start/end/limit/expire）
+// key【4】场次抢购结果key
+// arg【1】用户id
+// arg【2】排队中的抢购结果（JSON，包含预先生成的订单号）
 const seckillLua = `
-	--阶段1、防刷/幂等校验	
-	--检查用户是否在已购买集合中
-	if redis.call('sismember', KEYS[2], ARGV[1]) == 1 then
-		return -1 --返回-用户已购买
+	--阶段0、场次校验（使用 Redis 服务器时间）
+	local info = redis.call('hmget', KEYS[3], 'start', 'end', 'limit', 'expire')
+	if not info[1] then
+		return -5 --返回-场次不存在或未预热
+	end
+	local now = tonumber(redis.call('time')[1])
+	if now < tonumber(info[1]) then
+		return -3 --返回-未开始
+	end
+	if now >= tonumber(info[2]) then
+		return -4 --返回-已结束
+	end
+	--阶段1、限购校验
+	--检查用户在本场次已购数量
+	local limit = tonumber(info[3]) or 1
+	local bought = tonumber(redis.call('hget', KEYS[2], ARGV[1]) or '0')
+	if bought >= limit then
+		return -1 --返回-已达限购数量
 	end
 	--阶段2、库存校验
 	--获取当前库存
-	local stock = tonumber(redis.call('get', KEYS[1]))
+	local stock = tonumber(redis.call('get', KEYS[1]) or '0')
 	--判断库存是否充足
 	if stock <= 0 then
 		return -2 --返回-库存不足
@@ -27,13 +56,126 @@ const seckillLua = `
 	--阶段3、扣减库存/记录购买用户
 	--扣减库存
 	redis.call('decr', KEYS[1]) --库存-1
-	--将用户加入已购买集合
-	redis.call('sadd', KEYS[2], ARGV[1])
+	--用户已购数量+1
+	redis.call('hincrby', KEYS[2], ARGV[1], 1)
+	--记录抢购结果（排队中），供客户端轮询
+	redis.call('hset', KEYS[4], ARGV[1], ARGV[2])
+	--已购记录和抢购结果随场次一起过期
+	if info[4] then
+		redis.call('expireat', KEYS[2], info[4])
+		redis.call('expireat', KEYS[4], info[4])
+	end
 	--返回成功
 	return 1 --返回1表示抢购成功
 `
 
-//初始化脚本 需要在main函数启动时调用
+// 令牌桶限流脚本（多个维度一次校验）
+// key【i】第i个维度的令牌桶
+// arg【2i-1】第i个维度每秒生成的令牌数
+// arg【2i】第i个维度的桶容量
+// 返回 {1, 0} 放行；{0, 需等待毫秒数} 拒绝，任一维度令牌不足时所有维度都不扣减
+const rateLimitLua = `
+	--使用 Redis 服务器时间，避免各副本时钟不一致
+	local t = redis.call('time')
+	local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	--阶段1、按经过的时间补充令牌，计算各维度剩余令牌
+	local tokens = {}
+	local wait = 0
+	for i, key in ipairs(KEYS) do
+		local rate = tonumber(ARGV[i * 2 - 1])
+		local burst = tonumber(ARGV[i * 2])
+		local bucket = redis.call('hmget', key, 'tokens', 'ts')
+		local left = tonumber(bucket[1])
+		local ts = tonumber(bucket[2])
+		if left == nil then
+			left = burst
+			ts = now
+		end
+		left = math.min(burst, left + math.max(0, now - ts) * rate / 1000)
+		tokens[i] = left
+		if left < 1 then
+			wait = math.max(wait, math.ceil((1 - left) * 1000 / rate))
+		end
+	end
+	--阶段2、任一维度不足则拒绝
+	if wait > 0 then
+		return {0, wait}
+	end
+	--阶段3、所有维度各扣减一个令牌，空闲的桶自动过期
+	for i, key in ipairs(KEYS) do
+		local rate = tonumber(ARGV[i * 2 - 1])
+		local burst = tonumber(ARGV[i * 2])
+		redis.call('hset', key, 'tokens', tokens[i] - 1, 'ts', now)
+		redis.call('pexpire', key, math.ceil(burst * 1000 / rate) + 1000)
+	end
+	return {1, 0}
+`
+
+// 库存增减脚本
+// key【1】库存key
+// arg【1】增减数量（负数为减少）
+// 返回调整后的库存；库存不足以扣减时返回 -1 且不修改
+// 库存 key 不存在（未预热或 Redis 数据丢失）时返回 -2，不创建只有增量、没有过期时间的 key
+const adjustStockLua = `
+	local stock = tonumber(redis.call('get', KEYS[1]))
+	if not stock then
+		return -2
+	end
+	local delta = tonumber(ARGV[1])
+	if stock + delta < 0 then
+		return -1
+	end
+	return redis.call('incrby', KEYS[1], delta)
+`
+
+// 归还库存脚本
+// key【1】场次库存key
+// key【2】场次已购记录key
+// key【3】场次抢购结果key
+// arg【1】用户id
+// arg【2】归还数量
+// arg【3】新的抢购结果（JSON），为空时不修改
+// arg【4】为 1 时只归还库存、保留用户的限购名额（取消后禁止再次抢购），可省略
+// arg【5】为 1 时只归还限购名额、不归还库存（MySQL 已无库存，归还会让 Redis 库存虚高），可省略
+// 场次 key 已被清理时不再写入，避免留下没有过期时间的 key
+const releaseLua = `
+	if redis.call('exists', KEYS[1]) == 0 then
+		return 0
+	end
+	local n = tonumber(ARGV[2])
+	if ARGV[5] ~= '1' then
+		redis.call('incrby', KEYS[1], n)
+	end
+	if ARGV[4] ~= '1' then
+		local left = redis.call('hincrby', KEYS[2], ARGV[1], -n)
+		if left <= 0 then
+			redis.call('hdel', KEYS[2], ARGV[1])
+		end
+	end
+	if ARGV[3] ~= '' then
+		redis.call('hset', KEYS[3], ARGV[1], ARGV[3])
+	end
+	return 1
+`
+
+// 更新抢购结果脚本
+// key【1】场次抢购结果key
+// arg【1】用户id
+// arg【2】抢购结果（JSON）
+// 结果 key 由秒杀脚本创建并带有过期时间，不存在时（已过期或已清理）不再写入
+const setResultLua = `
+	if redis.call('exists', KEYS[1]) == 0 then
+		return 0
+	end
+	redis.call('hset', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+`
+
+// 初始化脚本 需要在main函数启动时调用
 func InitLuaScripts() {
 	SeckillScript = redis.NewScript(seckillLua)
+	RateLimitScript = redis.NewScript(rateLimitLua)
+	AdjustStockScript = redis.NewScript(adjustStockLua)
+	ReleaseScript = redis.NewScript(releaseLua)
+	SetResultScript = redis.NewScript(setResultLua)
 }
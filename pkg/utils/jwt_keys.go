@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"seckill/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT 签名密钥管理
+// 使用非对称算法（RS256 / EdDSA），签名私钥只在本服务持有，其他服务通过 JWKS 公钥验签
+// 轮换流程:
+//  1. 在 jwt.keys 中加入新密钥（此时仍用旧密钥签名，新公钥先发布到 JWKS）
+//  2. 各服务刷新 JWKS 后，把 jwt.active_kid 切换到新密钥
+//  3. 旧密钥签发的 token 全部过期后（expire_time 之后），从 jwt.keys 中删除旧密钥
+
+// signingKey 单个密钥
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer    // 签名私钥，只用于验签的旧密钥可以为空
+	public  crypto.PublicKey // 验签公钥
+}
+
+var (
+	keySet    map[string]*signingKey // kid -> 密钥
+	activeKey *signingKey            // 当前签名密钥
+	keysLock  sync.RWMutex
+)
+
+// InitJWTKeys 加载 JWT 密钥集，需要在 main 函数启动时调用
+func InitJWTKeys() error {
+	cfg := config.Get().JWT
+
+	set := make(map[string]*signingKey, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		if kc.KID == "" {
+			return errors.New("jwt.keys 中存在未设置 kid 的密钥")
+		}
+		key, err := loadKey(kc)
+		if err != nil {
+			return fmt.Errorf("加载 JWT 密钥 %s 失败: %w", kc.KID, err)
+		}
+		set[kc.KID] = key
+	}
+
+	var active *signingKey
+	if len(set) == 0 {
+		// 开发环境没有配置密钥时临时生成一把，重启后已签发的 token 全部失效
+		if config.IsReleaseMode() {
+			return errors.New("生产环境必须配置 jwt.keys")
+		}
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		kid, _ := RandomToken(4)
+		active = &signingKey{kid: "dev-" + kid, method: jwt.SigningMethodEdDSA, private: priv, public: pub}
+		set[active.kid] = active
+		fmt.Println("⚠️ 未配置 jwt.keys，使用临时生成的 Ed25519 密钥（仅限开发环境）")
+	} else {
+		active = set[cfg.ActiveKID]
+		if active == nil {
+			return fmt.Errorf("jwt.active_kid %q 不在 jwt.keys 中", cfg.ActiveKID)
+		}
+		if active.private == nil {
+			return fmt.Errorf("签名密钥 %s 未配置私钥", active.kid)
+		}
+	}
+
+	keysLock.Lock()
+	keySet = set
+	activeKey = active
+	keysLock.Unlock()
+
+	fmt.Printf("✅ JWT 密钥加载成功 [active=%s, alg=%s, keys=%d]\n", active.kid, active.method.Alg(), len(set))
+	return nil
+}
+
+// loadKey 读取 PEM 格式的私钥/公钥，并根据密钥类型确定签名算法
+func loadKey(kc config.JWTKeyConfig) (*signingKey, error) {
+	key := &signingKey{kid: kc.KID}
+
+	if kc.PrivateKeyFile != "" {
+		block, err := readPEM(kc.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			// 兼容 openssl genrsa 生成的 PKCS#1 格式
+			if rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes); rsaErr == nil {
+				priv = rsaKey
+			} else {
+				return nil, fmt.Errorf("解析私钥失败: %w", err)
+			}
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("不支持的私钥类型")
+		}
+		key.private = signer
+		key.public = signer.Public()
+	}
+
+	if kc.PublicKeyFile != "" {
+		block, err := readPEM(kc.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析公钥失败: %w", err)
+		}
+		key.public = pub
+	}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("RSA 密钥长度不能小于 2048 位")
+		}
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	case nil:
+		return nil, errors.New("private_key_file 和 public_key_file 至少配置一个")
+	default:
+		return nil, errors.New("只支持 RSA 和 Ed25519 密钥")
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s 不是有效的 PEM 文件", path)
+	}
+	return block, nil
+}
+
+// signingMethods 密钥集中出现的全部算法，解析 token 时只接受这些算法
+func signingMethods() []string {
+	keysLock.RLock()
+	defer keysLock.RUnlock()
+	seen := map[string]bool{}
+	var methods []string
+	for _, k := range keySet {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// verifyKey 根据 token 头部的 kid 选择验签公钥，并要求算法与密钥类型一致
+func verifyKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	keysLock.RLock()
+	key := keySet[kid]
+	keysLock.RUnlock()
+	if key == nil {
+		return nil, fmt.Errorf("未知的密钥ID: %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("签名算法 %s 与密钥 %s 不匹配", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+// JWKS 返回公钥集合（RFC 7517），供其他服务验签
+func JWKS() map[string]interface{} {
+	keysLock.RLock()
+	defer keysLock.RUnlock()
+
+	keys := make([]map[string]string, 0, len(keySet))
+	for _, k := range keySet {
+		jwk := map[string]string{
+			"kid": k.kid,
+			"alg": k.method.Alg(),
+			"use": "sig",
+		}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]interface{}{"keys": keys}
+}
@@ -2,6 +2,9 @@ package utils
 
 // 一些通用的工具函数
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"seckill/pkg/config"
@@ -10,16 +13,6 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// getJWTSecret 从配置获取 JWT 密钥
-func getJWTSecret() []byte {
-	return []byte(config.Get().JWT.Secret)
-}
-
-// getJWTExpireTime 从配置获取 JWT 过期时间
-func getJWTExpireTime() time.Duration {
-	return config.Get().JWT.ExpireTime
-}
-
 // HashPassword hash密码加密
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -32,25 +25,54 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken 生成JWT token字符串
-func GenerateToken(userID uint, username string) (string, error) {
+// RandomToken 生成 n 字节的随机串（十六进制），用于 jti、refresh token 等
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateToken 生成JWT access token字符串
+// 每个 token 带唯一 jti，登出时按 jti 加入黑名单
+// role 写入 token，后台接口鉴权不再查库；角色变更后需踢下线使旧 token 失效
+func GenerateToken(userID uint, username, role string) (string, error) {
 	cfg := config.Get().JWT
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
 	claims := jwt.MapClaims{
 		"uid":      userID,
 		"username": username,
-		"iss":      cfg.Issuer,                               // 签发者
-		"exp":      time.Now().Add(cfg.ExpireTime).Unix(),    // 过期时间
-		"iat":      time.Now().Unix(),                        // 签发时间
+		"role":     role,                                  // 角色
+		"jti":      jti,                                   // 令牌唯一ID
+		"iss":      cfg.Issuer,                            // 签发者
+		"exp":      time.Now().Add(cfg.ExpireTime).Unix(), // 过期时间
+		"iat":      time.Now().Unix(),                     // 签发时间
+	}
+
+	keysLock.RLock()
+	key := activeKey
+	keysLock.RUnlock()
+	if key == nil {
+		return "", errors.New("JWT 密钥未初始化")
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(getJWTSecret())
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid // 验签时按 kid 选择公钥
+	return token.SignedString(key.private)
 }
 
 // ParseToken 解析JWT token字符串
+// 严格校验：算法必须与 kid 对应密钥一致，签发者必须匹配，必须带过期时间
 func ParseToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return getJWTSecret(), nil
-	})
+	token, err := jwt.Parse(tokenString, verifyKey,
+		jwt.WithValidMethods(signingMethods()),
+		jwt.WithIssuer(config.Get().JWT.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		return nil, err
 	}